@@ -0,0 +1,295 @@
+// Package circuitbreaker provides a circuit breaker that stops calls to a failing dependency
+// for a cool-down period instead of amplifying load with retries. The breaker moves between
+// closed, open and half-open states based on consecutive failures and the failure rate
+// within a rolling window, and composes with retry.DoContext.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/retry"
+)
+
+const (
+	_defaultConsecutiveFailures = 5
+	_defaultMinRequests         = 10
+	_defaultWindowSize          = 10 * time.Second
+	_defaultWindowBuckets       = 10
+	_defaultOpenTimeout         = 10 * time.Second
+	_defaultHalfOpenProbes      = 1
+)
+
+var (
+	// ErrOpenState is returned when a call is rejected because the breaker is open.
+	ErrOpenState = errors.New("circuit breaker is open")
+	// ErrTooManyProbes is returned when a call is rejected because the half-open probe limit is reached.
+	ErrTooManyProbes = errors.New("circuit breaker half-open probe limit reached")
+)
+
+// State represents the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets all calls through and counts their outcomes.
+	StateClosed State = iota
+	// StateOpen rejects all calls until the open timeout expires.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through to test recovery.
+	StateHalfOpen
+)
+
+// String returns the string representation of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Counts is a snapshot of the breaker's counters within the rolling window.
+type Counts struct {
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+}
+
+// Breaker is a circuit breaker guarding calls to a single dependency.
+// A nil *Breaker is valid and executes every call directly, which lets clients
+// keep the breaker optional without extra checks.
+type Breaker struct {
+	name string
+
+	consecutiveFailures int
+	failureRate         float64
+	minRequests         int
+	windowSize          time.Duration
+	windowBuckets       int
+	openTimeout         time.Duration
+	halfOpenProbes      int
+	isFailure           func(err error) bool
+	onStateChange       func(name string, from, to State)
+	now                 func() time.Time
+
+	mu                sync.Mutex
+	state             State
+	generation        uint64
+	openedAt          time.Time
+	window            *window
+	consecutive       int
+	halfOpenInFlight  int
+	halfOpenSucceeded int
+}
+
+// New creates a circuit breaker with the given name and options.
+// The name is passed to state-change callbacks and included in rejection errors.
+// Returns an error if validation of options fails.
+func New(name string, opts ...Option) (*Breaker, error) {
+	b := &Breaker{
+		name:                name,
+		consecutiveFailures: _defaultConsecutiveFailures,
+		minRequests:         _defaultMinRequests,
+		windowSize:          _defaultWindowSize,
+		windowBuckets:       _defaultWindowBuckets,
+		openTimeout:         _defaultOpenTimeout,
+		halfOpenProbes:      _defaultHalfOpenProbes,
+		isFailure:           defaultIsFailure,
+		now:                 time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.isFailure == nil {
+		b.isFailure = defaultIsFailure
+	}
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("circuitbreaker.New: validation: %w", err)
+	}
+
+	b.window = newWindow(b.windowSize, b.windowBuckets, b.now())
+
+	return b, nil
+}
+
+// Name returns the breaker name.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current breaker state, moving from open to half-open
+// if the open timeout has expired.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState(b.now())
+	return b.state
+}
+
+// Counts returns a snapshot of the counters in the current rolling window.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	successes, failures := b.window.totals(b.now())
+	return Counts{
+		Successes:           successes,
+		Failures:            failures,
+		ConsecutiveFailures: b.consecutive,
+	}
+}
+
+// Execute runs fn if the breaker admits the call and records its outcome.
+// Rejected calls return ErrOpenState or ErrTooManyProbes wrapped with retry.Permanent,
+// so that a surrounding retry.DoContext stops immediately instead of hammering an open breaker.
+// If b is nil, fn is executed directly.
+func (b *Breaker) Execute(fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+
+	generation, err := b.admit()
+	if err != nil {
+		return err
+	}
+
+	// A panicking call is recorded as a failure.
+	success := false
+	defer func() {
+		b.record(generation, success)
+	}()
+
+	err = fn()
+	success = !b.isFailure(err)
+
+	return err
+}
+
+// Do runs fn through the breaker and returns its result. See Breaker.Execute.
+func Do[T any](b *Breaker, fn func() (T, error)) (T, error) {
+	var result T
+	err := b.Execute(func() error {
+		var e error
+		result, e = fn()
+		return e
+	})
+	return result, err
+}
+
+// admit checks whether a call may proceed and returns the generation it belongs to.
+func (b *Breaker) admit() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState(b.now())
+
+	switch b.state {
+	case StateOpen:
+		return 0, retry.Permanent(fmt.Errorf("%s: %w", b.name, ErrOpenState))
+	case StateHalfOpen:
+		if b.halfOpenInFlight+b.halfOpenSucceeded >= b.halfOpenProbes {
+			return 0, retry.Permanent(fmt.Errorf("%s: %w", b.name, ErrTooManyProbes))
+		}
+		b.halfOpenInFlight++
+	case StateClosed:
+	}
+
+	return b.generation, nil
+}
+
+// record registers the outcome of a call admitted in the given generation.
+// Outcomes of calls admitted before the last state change are ignored.
+func (b *Breaker) record(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refreshState(now)
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.window.record(now, success)
+		if success {
+			b.consecutive = 0
+			return
+		}
+		b.consecutive++
+		if b.shouldTrip(now) {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		b.halfOpenInFlight--
+		if !success {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.halfOpenSucceeded++
+		if b.halfOpenSucceeded >= b.halfOpenProbes {
+			b.setState(StateClosed, now)
+		}
+	case StateOpen:
+	}
+}
+
+// shouldTrip reports whether the closed breaker has crossed a failure threshold.
+func (b *Breaker) shouldTrip(now time.Time) bool {
+	if b.consecutiveFailures > 0 && b.consecutive >= b.consecutiveFailures {
+		return true
+	}
+
+	if b.failureRate <= 0 {
+		return false
+	}
+
+	successes, failures := b.window.totals(now)
+	total := successes + failures
+	return total >= b.minRequests && float64(failures)/float64(total) >= b.failureRate
+}
+
+// refreshState moves an open breaker to half-open once the open timeout has expired.
+func (b *Breaker) refreshState(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+// setState switches the breaker to a new state, resets counters and notifies the callback.
+func (b *Breaker) setState(state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.halfOpenInFlight = 0
+	b.halfOpenSucceeded = 0
+	b.window.reset(now)
+
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(b.name, from, state)
+	}
+}
+
+// defaultIsFailure treats every error except context cancellation as a failure.
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
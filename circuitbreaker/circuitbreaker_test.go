@@ -0,0 +1,150 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/circuitbreaker"
+	"github.com/wb-go/wbf/retry"
+)
+
+var errDown = errors.New("dependency down")
+
+func fail() error { return errDown }
+
+func succeed() error { return nil }
+
+func TestBreaker_OpensOnConsecutiveFailures(t *testing.T) {
+	var transitions []circuitbreaker.State
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(3),
+		circuitbreaker.OpenTimeout(time.Hour),
+		circuitbreaker.OnStateChange(func(_ string, _, to circuitbreaker.State) {
+			transitions = append(transitions, to)
+		}),
+	)
+	require.NoError(t, err)
+
+	for range 3 {
+		require.ErrorIs(t, b.Execute(fail), errDown)
+	}
+
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, []circuitbreaker.State{circuitbreaker.StateOpen}, transitions)
+
+	called := false
+	err = b.Execute(func() error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, circuitbreaker.ErrOpenState)
+	assert.False(t, called)
+}
+
+func TestBreaker_OpensOnFailureRate(t *testing.T) {
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(0),
+		circuitbreaker.FailureRate(0.5),
+		circuitbreaker.MinRequests(4),
+		circuitbreaker.OpenTimeout(time.Hour),
+	)
+	require.NoError(t, err)
+
+	_ = b.Execute(succeed)
+	_ = b.Execute(fail)
+	_ = b.Execute(succeed)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	_ = b.Execute(fail)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+}
+
+func TestBreaker_HalfOpenRecovery(t *testing.T) {
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.OpenTimeout(20*time.Millisecond),
+		circuitbreaker.HalfOpenProbes(2),
+	)
+	require.NoError(t, err)
+
+	_ = b.Execute(fail)
+	require.Equal(t, circuitbreaker.StateOpen, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+
+	require.NoError(t, b.Execute(succeed))
+	assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+	require.NoError(t, b.Execute(succeed))
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.OpenTimeout(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	_ = b.Execute(fail)
+	time.Sleep(30 * time.Millisecond)
+
+	_ = b.Execute(fail)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+}
+
+func TestBreaker_HalfOpenProbeLimit(t *testing.T) {
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.OpenTimeout(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	_ = b.Execute(fail)
+	time.Sleep(30 * time.Millisecond)
+
+	err = b.Execute(func() error {
+		return b.Execute(succeed)
+	})
+	require.ErrorIs(t, err, circuitbreaker.ErrTooManyProbes)
+}
+
+func TestBreaker_StopsRetries(t *testing.T) {
+	b, err := circuitbreaker.New("test",
+		circuitbreaker.ConsecutiveFailures(2),
+		circuitbreaker.OpenTimeout(time.Hour),
+	)
+	require.NoError(t, err)
+
+	calls := 0
+	err = retry.DoContext(context.Background(), retry.Strategy{Attempts: 10, Delay: time.Millisecond, Backoff: 1},
+		func() error {
+			return b.Execute(func() error {
+				calls++
+				return errDown
+			})
+		})
+
+	require.ErrorIs(t, err, circuitbreaker.ErrOpenState)
+	assert.Equal(t, 2, calls)
+}
+
+func TestBreaker_NilExecutesDirectly(t *testing.T) {
+	var b *circuitbreaker.Breaker
+
+	val, err := circuitbreaker.Do(b, func() (int, error) { return 42, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 42, val)
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := circuitbreaker.New("test", circuitbreaker.ConsecutiveFailures(0))
+	require.ErrorIs(t, err, circuitbreaker.ErrInvalidThresholds)
+
+	_, err = circuitbreaker.New("test", circuitbreaker.FailureRate(1.5))
+	require.ErrorIs(t, err, circuitbreaker.ErrInvalidFailureRate)
+}
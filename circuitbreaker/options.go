@@ -0,0 +1,128 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidThresholds is returned when both consecutive-failure and failure-rate thresholds are disabled.
+	ErrInvalidThresholds = errors.New("at least one of consecutiveFailures or failureRate must be set")
+	// ErrInvalidConsecutiveFailures is returned when ConsecutiveFailures < 0.
+	ErrInvalidConsecutiveFailures = errors.New("invalid consecutiveFailures: must be >= 0")
+	// ErrInvalidFailureRate is returned when FailureRate is outside [0, 1].
+	ErrInvalidFailureRate = errors.New("invalid failureRate: must be within [0, 1]")
+	// ErrInvalidMinRequests is returned when MinRequests <= 0.
+	ErrInvalidMinRequests = errors.New("invalid minRequests: must be > 0")
+	// ErrInvalidWindow is returned when the rolling window size or bucket count is not positive,
+	// or the window is shorter than one nanosecond per bucket.
+	ErrInvalidWindow = errors.New("invalid rolling window: size and buckets must be > 0 and size >= buckets")
+	// ErrInvalidOpenTimeout is returned when OpenTimeout <= 0.
+	ErrInvalidOpenTimeout = errors.New("invalid open timeout: must be > 0")
+	// ErrInvalidHalfOpenProbes is returned when HalfOpenProbes <= 0.
+	ErrInvalidHalfOpenProbes = errors.New("invalid halfOpenProbes: must be > 0")
+)
+
+// Option represents a functional configuration option for the circuit breaker.
+type Option func(*Breaker)
+
+// ConsecutiveFailures sets the number of consecutive failures that opens the breaker.
+// Zero disables the consecutive-failure threshold.
+func ConsecutiveFailures(n int) Option {
+	return func(b *Breaker) {
+		b.consecutiveFailures = n
+	}
+}
+
+// FailureRate sets the share of failed calls within the rolling window (from 0 to 1)
+// that opens the breaker once at least MinRequests calls were recorded.
+// Zero disables the failure-rate threshold.
+func FailureRate(rate float64) Option {
+	return func(b *Breaker) {
+		b.failureRate = rate
+	}
+}
+
+// MinRequests sets the minimum number of calls within the rolling window
+// before the failure rate is evaluated. The value must be greater than zero.
+func MinRequests(n int) Option {
+	return func(b *Breaker) {
+		b.minRequests = n
+	}
+}
+
+// RollingWindow sets the duration of the rolling window used for the failure rate
+// and the number of buckets it is divided into. Both values must be greater than zero.
+func RollingWindow(size time.Duration, buckets int) Option {
+	return func(b *Breaker) {
+		b.windowSize = size
+		b.windowBuckets = buckets
+	}
+}
+
+// OpenTimeout sets how long the breaker stays open before letting probe calls through.
+// The value must be greater than zero.
+func OpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.openTimeout = d
+	}
+}
+
+// HalfOpenProbes sets the number of probe calls admitted in the half-open state.
+// The breaker closes once all of them succeed and opens again on the first failure.
+// The value must be greater than zero.
+func HalfOpenProbes(n int) Option {
+	return func(b *Breaker) {
+		b.halfOpenProbes = n
+	}
+}
+
+// IsFailure sets the predicate deciding which call errors count as failures.
+// By default every error except context.Canceled is a failure.
+func IsFailure(fn func(err error) bool) Option {
+	return func(b *Breaker) {
+		b.isFailure = fn
+	}
+}
+
+// OnStateChange sets a callback invoked on every state transition.
+// The callback runs synchronously while the breaker is locked,
+// so it must be fast and must not call methods of the same breaker.
+func OnStateChange(fn func(name string, from, to State)) Option {
+	return func(b *Breaker) {
+		b.onStateChange = fn
+	}
+}
+
+// validate checks that all circuit breaker configuration parameters are valid.
+// It returns an error if any parameter violates its constraints.
+func (b *Breaker) validate() error {
+	if b.consecutiveFailures < 0 {
+		return ErrInvalidConsecutiveFailures
+	}
+
+	if b.failureRate < 0 || b.failureRate > 1 {
+		return ErrInvalidFailureRate
+	}
+
+	if b.consecutiveFailures == 0 && b.failureRate == 0 {
+		return ErrInvalidThresholds
+	}
+
+	if b.minRequests <= 0 {
+		return ErrInvalidMinRequests
+	}
+
+	if b.windowSize <= 0 || b.windowBuckets <= 0 || b.windowSize < time.Duration(b.windowBuckets) {
+		return ErrInvalidWindow
+	}
+
+	if b.openTimeout <= 0 {
+		return ErrInvalidOpenTimeout
+	}
+
+	if b.halfOpenProbes <= 0 {
+		return ErrInvalidHalfOpenProbes
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package circuitbreaker
+
+import (
+	"time"
+
+	"github.com/wb-go/wbf/internal/rolling"
+)
+
+// bucket holds outcome counters for a single slice of the rolling window.
+type bucket struct {
+	successes int
+	failures  int
+}
+
+// window is a rolling window of outcome counters split into fixed-size buckets.
+// Buckets older than the window size are discarded as time advances.
+type window struct {
+	buckets *rolling.Window[bucket]
+}
+
+// newWindow creates a rolling window of the given total size divided into n buckets.
+func newWindow(size time.Duration, n int, now time.Time) *window {
+	return &window{buckets: rolling.New[bucket](size, n, now)}
+}
+
+// record adds an outcome to the current bucket.
+func (w *window) record(now time.Time, success bool) {
+	b := w.buckets.Current(now)
+	if success {
+		b.successes++
+	} else {
+		b.failures++
+	}
+}
+
+// totals returns the number of successes and failures within the window.
+func (w *window) totals(now time.Time) (successes, failures int) {
+	for _, b := range w.buckets.Buckets(now) {
+		successes += b.successes
+		failures += b.failures
+	}
+	return successes, failures
+}
+
+// reset clears all buckets.
+func (w *window) reset(now time.Time) {
+	w.buckets.Reset(now)
+}
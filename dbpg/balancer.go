@@ -1,32 +1,190 @@
 package dbpg
 
-import "sync"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
 
+	"github.com/wb-go/wbf/internal/health"
+)
+
+// BalancerStrategy selects how read queries are distributed across healthy slaves.
+type BalancerStrategy string
+
+const (
+	// RoundRobin sends queries to healthy slaves in turn. It is the default strategy.
+	RoundRobin BalancerStrategy = "round_robin"
+	// Random sends each query to a randomly chosen healthy slave.
+	Random BalancerStrategy = "random"
+	// LeastConnections sends each query to the healthy slave with the fewest connections in use.
+	LeastConnections BalancerStrategy = "least_connections"
+	// Weighted sends queries to healthy slaves at random, proportionally to Options.SlaveWeights.
+	Weighted BalancerStrategy = "weighted"
+)
+
+const (
+	_defaultHealthCheckInterval = 5 * time.Second
+	_defaultHealthCheckTimeout  = time.Second
+)
+
+var (
+	// ErrUnknownBalancer is returned when Options.Balancer is not a supported strategy.
+	ErrUnknownBalancer = errors.New("unknown balancer strategy")
+	// ErrInvalidWeights is returned when Options.SlaveWeights does not match the slaves
+	// or contains negative values.
+	ErrInvalidWeights = errors.New("slave weights must be non-negative and match the number of slaves")
+)
+
+// balancer picks a healthy slave for read queries according to the configured strategy.
+// Slaves are considered healthy until a health check fails.
 type balancer struct {
-	idx       int
-	maxSlaves int // Number of slave connections.
+	strategy BalancerStrategy
+	slaves   []*sql.DB
+	weights  []int
+	health   *health.Checker
+	lags     []atomic.Int64 // Last observed replication lag per slave, in nanoseconds.
+	counter  atomic.Uint64
+}
+
+func newBalancer(slaves []*sql.DB, opts *Options) (*balancer, error) {
+	b := &balancer{
+		strategy: RoundRobin,
+		slaves:   slaves,
+		health:   health.NewChecker(len(slaves)),
+		lags:     make([]atomic.Int64, len(slaves)),
+	}
+
+	if opts == nil {
+		return b, nil
+	}
+
+	switch opts.Balancer {
+	case "":
+	case RoundRobin, Random, LeastConnections, Weighted:
+		b.strategy = opts.Balancer
+	default:
+		return nil, ErrUnknownBalancer
+	}
+
+	if b.strategy == Weighted {
+		if len(opts.SlaveWeights) != len(slaves) {
+			return nil, ErrInvalidWeights
+		}
+		for _, w := range opts.SlaveWeights {
+			if w < 0 {
+				return nil, ErrInvalidWeights
+			}
+		}
+		b.weights = opts.SlaveWeights
+	}
+
+	return b, nil
+}
+
+// pick returns the index of a healthy slave that is not excluded.
+// It returns false if no such slave exists and the query should go to the master.
+func (b *balancer) pick(exclude func(idx int) bool) (int, bool) {
+	candidates := make([]int, 0, len(b.slaves))
+	for i := range b.slaves {
+		if b.health.Healthy(i) && (exclude == nil || !exclude(i)) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+
+	switch b.strategy {
+	case Random:
+		//nolint:gosec
+		return candidates[rand.IntN(len(candidates))], true
+	case LeastConnections:
+		return b.leastConnections(candidates), true
+	case Weighted:
+		return b.weighted(candidates), true
+	case RoundRobin:
+	}
+	return candidates[b.next(len(candidates))], true
+}
+
+// next returns the next round-robin position among n candidates.
+func (b *balancer) next(n int) int {
+	return int((b.counter.Add(1) - 1) % uint64(n))
+}
+
+// leastConnections returns the candidate with the fewest connections in use.
+// The scan starts at a rotating offset so that ties are spread evenly.
+func (b *balancer) leastConnections(candidates []int) int {
+	start := b.next(len(candidates))
+	best, bestInUse := -1, 0
+	for i := range candidates {
+		idx := candidates[(start+i)%len(candidates)]
+		inUse := b.slaves[idx].Stats().InUse
+		if best < 0 || inUse < bestInUse {
+			best, bestInUse = idx, inUse
+		}
+	}
+	return best
+}
 
-	mu *sync.Mutex
+// weighted returns a random candidate chosen proportionally to its weight.
+// If all candidates have zero weight, it falls back to round-robin.
+func (b *balancer) weighted(candidates []int) int {
+	total := 0
+	for _, idx := range candidates {
+		total += b.weights[idx]
+	}
+	if total == 0 {
+		return candidates[b.next(len(candidates))]
+	}
+
+	//nolint:gosec
+	n := rand.IntN(total)
+	for _, idx := range candidates {
+		n -= b.weights[idx]
+		if n < 0 {
+			return idx
+		}
+	}
+	return candidates[len(candidates)-1]
 }
 
-func newBalancer(maxSlaves int) *balancer {
-	return &balancer{
-		idx:       0,
-		maxSlaves: maxSlaves,
-		mu:        &sync.Mutex{},
+// healthyCount returns the number of slaves currently considered healthy.
+func (b *balancer) healthyCount() int {
+	return b.health.HealthyCount()
+}
+
+// replicationLags returns the last observed replication lag of every slave.
+func (b *balancer) replicationLags() []time.Duration {
+	lags := make([]time.Duration, len(b.lags))
+	for i := range b.lags {
+		lags[i] = time.Duration(b.lags[i].Load())
 	}
+	return lags
 }
 
-func (b *balancer) index() int {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+// setReplicationLag stores the observed replication lag of the slave at idx.
+func (b *balancer) setReplicationLag(idx int, lag time.Duration) {
+	b.lags[idx].Store(int64(lag))
+}
 
-	if b.maxSlaves <= 0 {
-		return 0
+// startHealthChecks runs check against every slave right away and then each interval,
+// ejecting slaves whose check fails and re-admitting them once it succeeds again.
+func (b *balancer) startHealthChecks(interval, timeout time.Duration, check func(ctx context.Context, idx int) bool) {
+	if interval <= 0 {
+		interval = _defaultHealthCheckInterval
+	}
+	if timeout <= 0 {
+		timeout = _defaultHealthCheckTimeout
 	}
 
-	res := b.idx
-	b.idx = (b.idx + 1) % b.maxSlaves
+	b.health.Start(interval, timeout, check)
+}
 
-	return res
+// close stops health checks and waits for the running check to finish.
+func (b *balancer) close() {
+	b.health.Stop()
 }
@@ -0,0 +1,146 @@
+package dbpg_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// newSlaves returns fake slaves with the given names and their connections.
+func newSlaves(names ...string) ([]*fakeDB, []*sql.DB) {
+	fakes := make([]*fakeDB, len(names))
+	conns := make([]*sql.DB, len(names))
+	for i, name := range names {
+		fakes[i] = newFakeDB(name)
+		conns[i] = fakes[i].open()
+	}
+	return fakes, conns
+}
+
+// readFrom runs n reads and counts how many of them each database answered.
+func readFrom(t *testing.T, db *dbpg.DB, n int) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for range n {
+		counts[queryValue(t, context.Background(), db)]++
+	}
+	return counts
+}
+
+func TestBalancer_Strategies(t *testing.T) {
+	tests := []struct {
+		name string
+		opts dbpg.Options
+		want map[string]int
+	}{
+		{
+			name: "round robin",
+			opts: dbpg.Options{},
+			want: map[string]int{"a": 3, "b": 3, "c": 3},
+		},
+		{
+			name: "weighted skips zero weights",
+			opts: dbpg.Options{Balancer: dbpg.Weighted, SlaveWeights: []int{0, 1, 0}},
+			want: map[string]int{"b": 9},
+		},
+		{
+			name: "weighted with all zero weights falls back to round robin",
+			opts: dbpg.Options{Balancer: dbpg.Weighted, SlaveWeights: []int{0, 0, 0}},
+			want: map[string]int{"a": 3, "b": 3, "c": 3},
+		},
+		{
+			name: "least connections spreads ties",
+			opts: dbpg.Options{Balancer: dbpg.LeastConnections},
+			want: map[string]int{"a": 3, "b": 3, "c": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, slaves := newSlaves("a", "b", "c")
+			db, err := dbpg.NewFromConns(newFakeDB("master").open(), slaves, &tt.opts)
+			require.NoError(t, err)
+			defer db.Close()
+
+			assert.Equal(t, tt.want, readFrom(t, db, 9))
+		})
+	}
+}
+
+func TestBalancer_Random(t *testing.T) {
+	_, slaves := newSlaves("a", "b")
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), slaves, &dbpg.Options{Balancer: dbpg.Random})
+	require.NoError(t, err)
+	defer db.Close()
+
+	counts := readFrom(t, db, 50)
+	assert.Zero(t, counts["master"])
+	assert.Equal(t, 50, counts["a"]+counts["b"])
+}
+
+func TestBalancer_LeastConnections(t *testing.T) {
+	_, slaves := newSlaves("a", "b")
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), slaves, &dbpg.Options{Balancer: dbpg.LeastConnections})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Keep a connection of slave a busy: every read must go to slave b.
+	conn, err := slaves[0].Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, map[string]int{"b": 4}, readFrom(t, db, 4))
+}
+
+func TestBalancer_Validation(t *testing.T) {
+	_, slaves := newSlaves("a", "b")
+	master := newFakeDB("master").open()
+
+	_, err := dbpg.NewFromConns(master, slaves, &dbpg.Options{Balancer: "fastest"})
+	require.ErrorIs(t, err, dbpg.ErrUnknownBalancer)
+
+	_, err = dbpg.NewFromConns(master, slaves, &dbpg.Options{Balancer: dbpg.Weighted, SlaveWeights: []int{1}})
+	require.ErrorIs(t, err, dbpg.ErrInvalidWeights)
+
+	_, err = dbpg.NewFromConns(master, slaves, &dbpg.Options{Balancer: dbpg.Weighted, SlaveWeights: []int{1, -1}})
+	require.ErrorIs(t, err, dbpg.ErrInvalidWeights)
+}
+
+func TestBalancer_HealthChecks(t *testing.T) {
+	fakes, slaves := newSlaves("a", "b")
+	down := make([]atomic.Bool, len(fakes))
+	for i, f := range fakes {
+		f.exec = func(context.Context, string) error {
+			if down[i].Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+	}
+	down[0].Store(true)
+
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), slaves,
+		&dbpg.Options{HealthCheckInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The first check runs before New returns: the dead slave never receives reads.
+	assert.Equal(t, 1, db.HealthySlaves())
+	assert.Equal(t, map[string]int{"b": 4}, readFrom(t, db, 4))
+
+	down[0].Store(false)
+	require.Eventually(t, func() bool { return db.HealthySlaves() == 2 }, time.Second, 5*time.Millisecond)
+
+	down[0].Store(true)
+	down[1].Store(true)
+	require.Eventually(t, func() bool { return db.HealthySlaves() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[string]int{"master": 2}, readFrom(t, db, 2))
+}
@@ -0,0 +1,272 @@
+package dbpg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultBatchSize     = 100
+	_defaultFlushInterval = 100 * time.Millisecond
+)
+
+// ErrBatchWriterClosed is returned when a statement is enqueued to or flushed on a closed BatchWriter.
+var ErrBatchWriterClosed = errors.New("batch writer is closed")
+
+// BatchOptions defines BatchWriter thresholds. Zero values select the defaults.
+type BatchOptions struct {
+	// MaxBatchSize is the maximum number of statements executed in one transaction (100 by default).
+	MaxBatchSize int
+	// FlushInterval is the maximum time a queued statement waits for its batch to fill up (100ms by default).
+	FlushInterval time.Duration
+	// QueueSize is the number of statements that can be queued before Enqueue blocks
+	// (MaxBatchSize by default).
+	QueueSize int
+	// ResultBufferSize is the capacity of the Results channel (QueueSize by default).
+	ResultBufferSize int
+}
+
+// Statement is a command queued to a BatchWriter.
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// BatchResult reports the outcome of a queued statement.
+// Err is nil if the statement was committed.
+type BatchResult struct {
+	Statement Statement
+	Err       error
+}
+
+// BatchWriter groups queued statements into transactions on the master database.
+// A batch is executed when it reaches MaxBatchSize statements or FlushInterval after
+// its first statement was queued. A failing statement does not fail its batch:
+// it is reported on Results and the rest of the batch is executed again without it.
+type BatchWriter struct {
+	db            *DB
+	ctx           context.Context
+	maxBatchSize  int
+	flushInterval time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}  // Closed by Close to release senders blocked on a full queue.
+	senders sync.WaitGroup // Senders that may still put a request into the queue.
+	queue   chan batchRequest
+	results chan BatchResult
+	done    chan struct{}
+}
+
+// batchRequest is either a statement or, if flushed is set, a flush marker.
+type batchRequest struct {
+	stmt    Statement
+	flushed chan struct{}
+}
+
+// NewBatchWriter starts a batch writer executing statements with ctx.
+// Results must be drained: once its buffer is full, the writer stops executing batches
+// and Enqueue blocks. The writer must be stopped with Close.
+func (db *DB) NewBatchWriter(ctx context.Context, opts *BatchOptions) *BatchWriter {
+	w := &BatchWriter{
+		db:            db,
+		ctx:           ctx,
+		maxBatchSize:  _defaultBatchSize,
+		flushInterval: _defaultFlushInterval,
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	queueSize, resultBufferSize := 0, 0
+	if opts != nil {
+		if opts.MaxBatchSize > 0 {
+			w.maxBatchSize = opts.MaxBatchSize
+		}
+		if opts.FlushInterval > 0 {
+			w.flushInterval = opts.FlushInterval
+		}
+		queueSize, resultBufferSize = opts.QueueSize, opts.ResultBufferSize
+	}
+	if queueSize <= 0 {
+		queueSize = w.maxBatchSize
+	}
+	if resultBufferSize <= 0 {
+		resultBufferSize = queueSize
+	}
+
+	w.queue = make(chan batchRequest, queueSize)
+	w.results = make(chan BatchResult, resultBufferSize)
+
+	go w.run()
+
+	return w
+}
+
+// Enqueue queues a statement for execution. It blocks while the queue is full
+// and returns ctx.Err() if ctx is done first, or ErrBatchWriterClosed after Close.
+func (w *BatchWriter) Enqueue(ctx context.Context, query string, args ...any) error {
+	return w.send(ctx, batchRequest{stmt: Statement{Query: query, Args: args}})
+}
+
+// Results returns the channel receiving the outcome of every queued statement.
+// It is closed after Close has executed the remaining statements.
+func (w *BatchWriter) Results() <-chan BatchResult {
+	return w.results
+}
+
+// Flush executes all statements queued before the call and waits until
+// their results are sent to Results.
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	if err := w.send(ctx, batchRequest{flushed: flushed}); err != nil {
+		return err
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting statements, executes the queued ones and closes Results.
+// Enqueue and Flush calls blocked on a full queue return ErrBatchWriterClosed.
+// It returns ctx.Err() if ctx is done before the remaining statements are executed;
+// the writer keeps finishing them in the background.
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.closing)
+		// The queue is closed once no sender can put a request into it anymore.
+		go func() {
+			w.senders.Wait()
+			close(w.queue)
+		}()
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send puts a request into the queue unless the writer is closed.
+// The lock only guards the closed check, so Close never waits for blocked senders.
+func (w *BatchWriter) send(ctx context.Context, req batchRequest) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return ErrBatchWriterClosed
+	}
+	w.senders.Add(1)
+	w.mu.RUnlock()
+	defer w.senders.Done()
+
+	select {
+	case w.queue <- req:
+		return nil
+	case <-w.closing:
+		return ErrBatchWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run collects queued statements into batches until the queue is closed.
+func (w *BatchWriter) run() {
+	defer close(w.done)
+	defer close(w.results)
+
+	timer := time.NewTimer(w.flushInterval)
+	timer.Stop()
+	defer timer.Stop()
+
+	batch := make([]Statement, 0, w.maxBatchSize)
+	flush := func() {
+		timer.Stop()
+		if len(batch) > 0 {
+			w.execute(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case req, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if req.flushed != nil {
+				flush()
+				close(req.flushed)
+				continue
+			}
+
+			batch = append(batch, req.stmt)
+			if len(batch) == 1 {
+				timer.Reset(w.flushInterval)
+			}
+			if len(batch) >= w.maxBatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// execute runs the batch in a transaction and reports the outcome of every statement.
+// When a statement fails, the transaction is rolled back and retried without it,
+// so each failure costs one extra round of the remaining statements.
+func (w *BatchWriter) execute(batch []Statement) {
+	pending := batch
+	for len(pending) > 0 {
+		failed, err := w.executeTx(pending)
+		if failed < 0 {
+			for _, stmt := range pending {
+				w.results <- BatchResult{Statement: stmt, Err: err}
+			}
+			if err == nil {
+				markWrite(w.ctx)
+			}
+			return
+		}
+
+		w.results <- BatchResult{Statement: pending[failed], Err: err}
+		// Copy instead of shifting in place: the batch buffer is reused by run.
+		pending = append(pending[:failed:failed], pending[failed+1:]...)
+
+		if ctxErr := w.ctx.Err(); ctxErr != nil {
+			for _, stmt := range pending {
+				w.results <- BatchResult{Statement: stmt, Err: ctxErr}
+			}
+			return
+		}
+	}
+}
+
+// executeTx executes statements in a single transaction. It returns the index of the
+// failing statement and its error, or -1 and the error of beginning or committing the transaction.
+func (w *BatchWriter) executeTx(stmts []Statement) (int, error) {
+	tx, err := w.db.BeginTx(w.ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	for i, stmt := range stmts {
+		if _, err := tx.ExecContext(w.ctx, stmt.Query, stmt.Args...); err != nil {
+			_ = tx.Rollback()
+			return i, err
+		}
+	}
+
+	return -1, tx.Commit()
+}
@@ -0,0 +1,130 @@
+package dbpg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// newBatchDB returns a DB whose master fails the statements listed in fail.
+func newBatchDB(t *testing.T, fail map[string]error) (*dbpg.DB, *fakeDB) {
+	t.Helper()
+
+	master := newFakeDB("master")
+	master.exec = func(_ context.Context, query string) error { return fail[query] }
+
+	db, err := dbpg.NewFromConns(master.open(), nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db, master
+}
+
+// collect reads n results from the writer.
+func collect(t *testing.T, w *dbpg.BatchWriter, n int) map[string]error {
+	t.Helper()
+
+	results := make(map[string]error, n)
+	for range n {
+		select {
+		case res := <-w.Results():
+			results[res.Statement.Query] = res.Err
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for batch results")
+		}
+	}
+	return results
+}
+
+func TestBatchWriter_Flush(t *testing.T) {
+	db, master := newBatchDB(t, nil)
+	w := db.NewBatchWriter(context.Background(), &dbpg.BatchOptions{FlushInterval: time.Hour})
+	defer w.Close(context.Background())
+
+	ctx := context.Background()
+	require.NoError(t, w.Enqueue(ctx, "INSERT 1", 1))
+	require.NoError(t, w.Enqueue(ctx, "INSERT 2", 2))
+	require.NoError(t, w.Flush(ctx))
+
+	assert.Equal(t, map[string]error{"INSERT 1": nil, "INSERT 2": nil}, collect(t, w, 2))
+	assert.Equal(t, []string{"INSERT 1", "INSERT 2"}, master.Committed())
+}
+
+func TestBatchWriter_StatementErrors(t *testing.T) {
+	dup := errors.New("duplicate key")
+	db, master := newBatchDB(t, map[string]error{"INSERT bad": dup})
+	w := db.NewBatchWriter(context.Background(), &dbpg.BatchOptions{MaxBatchSize: 3, FlushInterval: time.Hour})
+	defer w.Close(context.Background())
+
+	ctx := context.Background()
+	for _, query := range []string{"INSERT 1", "INSERT bad", "INSERT 2"} {
+		require.NoError(t, w.Enqueue(ctx, query))
+	}
+
+	results := collect(t, w, 3)
+	require.ErrorIs(t, results["INSERT bad"], dup)
+	assert.NoError(t, results["INSERT 1"])
+	assert.NoError(t, results["INSERT 2"])
+	assert.Equal(t, []string{"INSERT 1", "INSERT 2"}, master.Committed())
+}
+
+func TestBatchWriter_Close(t *testing.T) {
+	db, master := newBatchDB(t, nil)
+	w := db.NewBatchWriter(context.Background(), &dbpg.BatchOptions{FlushInterval: time.Hour})
+
+	ctx := context.Background()
+	require.NoError(t, w.Enqueue(ctx, "INSERT 1"))
+	require.NoError(t, w.Close(ctx))
+
+	assert.Equal(t, map[string]error{"INSERT 1": nil}, collect(t, w, 1))
+	_, open := <-w.Results()
+	assert.False(t, open)
+	assert.Equal(t, []string{"INSERT 1"}, master.Committed())
+
+	require.ErrorIs(t, w.Enqueue(ctx, "INSERT 2"), dbpg.ErrBatchWriterClosed)
+	require.ErrorIs(t, w.Flush(ctx), dbpg.ErrBatchWriterClosed)
+	require.NoError(t, w.Close(ctx))
+}
+
+func TestBatchWriter_Backpressure(t *testing.T) {
+	db, _ := newBatchDB(t, nil)
+	w := db.NewBatchWriter(context.Background(), &dbpg.BatchOptions{
+		MaxBatchSize:     1,
+		QueueSize:        1,
+		ResultBufferSize: 1,
+	})
+
+	// The first result fills the buffer, the writer blocks sending the second one
+	// and the third statement fills the queue.
+	ctx := context.Background()
+	for _, query := range []string{"INSERT 1", "INSERT 2", "INSERT 3"} {
+		require.NoError(t, w.Enqueue(ctx, query))
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, w.Enqueue(timeoutCtx, "INSERT 4"), context.DeadlineExceeded)
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- w.Enqueue(ctx, "INSERT 5") }()
+
+	// Close does not wait for the blocked sender and honours its context.
+	closeCtx, cancelClose := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancelClose()
+	require.ErrorIs(t, w.Close(closeCtx), context.DeadlineExceeded)
+
+	select {
+	case err := <-blocked:
+		require.ErrorIs(t, err, dbpg.ErrBatchWriterClosed)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Enqueue stayed blocked after Close")
+	}
+
+	assert.Equal(t, map[string]error{"INSERT 1": nil, "INSERT 2": nil, "INSERT 3": nil}, collect(t, w, 3))
+	require.NoError(t, w.Close(ctx))
+}
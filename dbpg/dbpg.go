@@ -4,17 +4,27 @@ package dbpg
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
+	"sync"
 	"time"
 
 	// Register PostgreSQL driver for database/sql.
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+	"github.com/wb-go/wbf/circuitbreaker"
+	"github.com/wb-go/wbf/dbpg/pgerr"
 	"github.com/wb-go/wbf/retry"
 )
 
 // DB represents a database connection with master and slave nodes.
 type DB struct {
-	balancer *balancer
+	balancer             *balancer
+	breaker              *circuitbreaker.Breaker
+	hedgeDelay           time.Duration
+	maxHedges            int
+	maxReplicationLag    time.Duration
+	readYourWritesWindow time.Duration
 
 	Master *sql.DB
 	Slaves []*sql.DB
@@ -25,6 +35,38 @@ type Options struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+
+	// CircuitBreaker guards queries, commands and transaction starts (optional).
+	// Errors caused by the statement itself (SQLSTATE classes 22, 23 and 42: data,
+	// constraint and syntax or access errors) are returned to the caller but do not
+	// count as breaker failures.
+	CircuitBreaker *circuitbreaker.Breaker
+
+	// HedgeDelay enables hedged reads in QueryContext: if a slave has not answered
+	// within this delay, the query is also sent to another slave (0 disables hedging).
+	HedgeDelay time.Duration
+	// MaxHedges is the maximum number of additional hedged queries (at least 1 when
+	// hedging is enabled, at most the number of slaves minus one).
+	MaxHedges int
+
+	// Balancer selects how reads are distributed across healthy slaves (RoundRobin by default).
+	Balancer BalancerStrategy
+	// SlaveWeights sets per-slave weights for the Weighted balancer, in the order of slave DSNs.
+	SlaveWeights []int
+	// HealthCheckInterval enables periodic pings of every slave: failing slaves are ejected
+	// from balancing and re-admitted after recovery (0 disables health checks).
+	// The first check runs in New, so slaves that are down at startup never receive reads.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout limits a single slave ping (1 second by default).
+	HealthCheckTimeout time.Duration
+
+	// MaxReplicationLag excludes slaves whose replay lag exceeds this value from reads.
+	// The lag is polled on every health check (every 5 seconds if HealthCheckInterval is not set).
+	// Zero disables lag monitoring.
+	MaxReplicationLag time.Duration
+	// ReadYourWritesWindow pins reads to the master for this long after a write made
+	// with a context prepared by WithReadYourWrites (0 disables pinning).
+	ReadYourWritesWindow time.Duration
 }
 
 func applyOptions(db *sql.DB, opts *Options) {
@@ -61,25 +103,185 @@ func New(masterDSN string, slaveDSNs []string, opts *Options) (*DB, error) {
 		slaves = append(slaves, slave)
 	}
 
+	return newDB(master, slaves, opts)
+}
+
+// newDB creates a DB on opened master and slave connections.
+func newDB(master *sql.DB, slaves []*sql.DB, opts *Options) (*DB, error) {
 	// Create balancer.
-	balancer := newBalancer(len(slaveDSNs))
+	balancer, err := newBalancer(slaves, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Master: master, Slaves: slaves, balancer: balancer}
+	if opts != nil {
+		db.breaker = opts.CircuitBreaker
+		db.hedgeDelay = opts.HedgeDelay
+		db.maxHedges = min(max(opts.MaxHedges, 1), len(slaves)-1)
+		db.maxReplicationLag = opts.MaxReplicationLag
+		db.readYourWritesWindow = opts.ReadYourWritesWindow
+
+		if (opts.HealthCheckInterval > 0 || opts.MaxReplicationLag > 0) && len(slaves) > 0 {
+			balancer.startHealthChecks(opts.HealthCheckInterval, opts.HealthCheckTimeout, db.checkSlave)
+		}
+	}
+
+	return db, nil
+}
+
+// replicationLagQuery returns the replay lag of a standby in seconds.
+// A standby streaming from the primary that has replayed everything it received
+// reports zero lag, so an idle master does not make its replicas look stale.
+// Without a streaming WAL receiver the received position is stale as well, so the lag
+// is the age of the last replayed transaction (NULL if nothing was replayed yet).
+// A node that is not in recovery is not a standby and reports zero lag.
+const replicationLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN NOT EXISTS (
+		SELECT 1 FROM pg_stat_wal_receiver WHERE COALESCE(status, 'streaming') = 'streaming'
+	) THEN EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// checkSlave reports whether the slave at idx is available for reads: it must answer
+// and, with lag monitoring enabled, its replication lag must be known and must not exceed MaxReplicationLag.
+func (db *DB) checkSlave(ctx context.Context, idx int) bool {
+	if db.maxReplicationLag <= 0 {
+		return db.Slaves[idx].PingContext(ctx) == nil
+	}
+
+	var seconds sql.NullFloat64
+	if err := db.Slaves[idx].QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil || !seconds.Valid {
+		return false
+	}
+
+	lag := time.Duration(seconds.Float64 * float64(time.Second))
+	db.balancer.setReplicationLag(idx, lag)
+
+	return lag <= db.maxReplicationLag
+}
 
-	return &DB{Master: master, Slaves: slaves, balancer: balancer}, nil
+// ReplicationLags returns the last observed replication lag of every slave,
+// in the order of slave DSNs. Lags are only measured when MaxReplicationLag is set.
+func (db *DB) ReplicationLags() []time.Duration {
+	return db.balancer.replicationLags()
+}
+
+// HealthySlaves returns the number of slaves currently used for reads.
+func (db *DB) HealthySlaves() int {
+	return db.balancer.healthyCount()
+}
+
+// Close stops health checks and closes the master and all slave connections.
+func (db *DB) Close() error {
+	db.balancer.close()
+
+	errs := make([]error, 0, len(db.Slaves)+1)
+	errs = append(errs, db.Master.Close())
+	for _, slave := range db.Slaves {
+		errs = append(errs, slave.Close())
+	}
+	return errors.Join(errs...)
 }
 
 // QueryContext executes a query on a slave if available, otherwise on the master.
+// Routing can be overridden with WithMaster and WithReplica; write statements
+// (INSERT/UPDATE/DELETE, SELECT ... FOR UPDATE, etc.) always go to the master.
+// With hedging enabled (Options.HedgeDelay), slow queries are duplicated to other slaves
+// and the first successful result is returned; ctx then bounds the query until the rows
+// are returned, and cancelling it later does not interrupt reading them.
 func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return db.selectDB().QueryContext(ctx, query, args...)
+	write := isWriteStatement(query)
+	rows, err := breakerDo(db.breaker, func() (*sql.Rows, error) {
+		target, slave := db.selectDB(ctx, write)
+		if slave >= 0 && db.hedgeDelay > 0 && db.maxHedges > 0 {
+			return db.hedgedQuery(ctx, slave, query, args...)
+		}
+		return target.QueryContext(ctx, query, args...)
+	})
+	if err == nil && write {
+		markWrite(ctx)
+	}
+	return rows, err
+}
+
+// hedgedRows is the result of a hedged query attempt.
+type hedgedRows struct {
+	rows   *sql.Rows
+	detach func() bool // Stops cancelling the rows together with the attempt.
+}
+
+// hedgedQuery sends the query to the slave at first and, after each hedge delay
+// without an answer, to another healthy slave not used yet (or to the master if none is left).
+// Every attempt queries on a context detached from the attempt context, so that the
+// winner's rows outlive retry.Hedge without keeping a context registered in ctx;
+// losing attempts are cancelled through their attempt contexts, which closes their rows.
+func (db *DB) hedgedQuery(ctx context.Context, first int, query string, args ...interface{}) (*sql.Rows, error) {
+	var mu sync.Mutex
+	used := make(map[int]bool, db.maxHedges+1)
+
+	res, release, err := retry.HedgeWithRelease(ctx, db.hedgeDelay, db.maxHedges,
+		func(attemptCtx context.Context) (hedgedRows, error) {
+			mu.Lock()
+			idx, ok := first, true
+			if len(used) > 0 {
+				idx, ok = db.balancer.pick(func(i int) bool { return used[i] })
+			}
+			if ok {
+				used[idx] = true
+			}
+			mu.Unlock()
+
+			target := db.Master
+			if ok {
+				target = db.Slaves[idx]
+			}
+
+			queryCtx, cancel := context.WithCancel(context.WithoutCancel(attemptCtx))
+			detach := context.AfterFunc(attemptCtx, cancel)
+
+			rows, err := target.QueryContext(queryCtx, query, args...)
+			if err != nil {
+				cancel()
+				return hedgedRows{}, err
+			}
+			return hedgedRows{rows: rows, detach: detach}, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	res.detach()
+	release()
+
+	return res.rows, nil
 }
 
 // QueryRowContext executes a single-row query on a slave if available, otherwise on the master.
+// It bypasses the circuit breaker because *sql.Row cannot carry a rejection error;
+// use QueryRowWithRetry to run single-row queries through the breaker.
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return db.selectDB().QueryRowContext(ctx, query, args...)
+	write := isWriteStatement(query)
+	target, _ := db.selectDB(ctx, write)
+
+	row := target.QueryRowContext(ctx, query, args...)
+	if write && row.Err() == nil {
+		markWrite(ctx)
+	}
+	return row
 }
 
 // ExecContext executes a command on the master database.
 func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return db.Master.ExecContext(ctx, query, args...)
+	res, err := breakerDo(db.breaker, func() (sql.Result, error) {
+		return db.Master.ExecContext(ctx, query, args...)
+	})
+	if err == nil {
+		markWrite(ctx)
+	}
+	return res, err
 }
 
 // ExecWithRetry executes a command with a retry strategy.
@@ -89,15 +291,9 @@ func (db *DB) ExecWithRetry(
 	query string,
 	args ...interface{},
 ) (sql.Result, error) {
-	var res sql.Result
-	err := retry.Do(func() error {
-		r, e := db.ExecContext(ctx, query, args...)
-		if e == nil {
-			res = r
-		}
-		return e
-	}, strategy)
-	return res, err
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (sql.Result, error) {
+		return db.ExecContext(ctx, query, args...)
+	})
 }
 
 // QueryWithRetry executes a query with a retry strategy.
@@ -107,22 +303,17 @@ func (db *DB) QueryWithRetry(
 	query string,
 	args ...interface{},
 ) (*sql.Rows, error) {
-	var rows *sql.Rows
-	err := retry.Do(func() error {
-		r, e := db.QueryContext(ctx, query, args...)
-		if e == nil {
-			if rowsErr := r.Err(); rowsErr != nil {
-				defer func() {
-					_ = r.Close()
-				}()
-				return rowsErr
-			}
-			rows = r
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (*sql.Rows, error) {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
 		}
-		return e
-	}, strategy)
-
-	return rows, err
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		return rows, nil
+	})
 }
 
 // QueryRowWithRetry executes a single-row query with a retry strategy.
@@ -132,43 +323,63 @@ func (db *DB) QueryRowWithRetry(
 	query string,
 	args ...interface{},
 ) (*sql.Row, error) {
-	var row *sql.Row
-	err := retry.Do(func() error {
-		r := db.QueryRowContext(ctx, query, args...)
-		row = r
-		return r.Err()
-	}, strategy)
-
-	return row, err
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (*sql.Row, error) {
+		return breakerDo(db.breaker, func() (*sql.Row, error) {
+			row := db.QueryRowContext(ctx, query, args...)
+			return row, row.Err()
+		})
+	})
 }
 
-// BatchExec executes multiple queries asynchronously in a batch.
+// BatchExec executes queries from the channel asynchronously, grouped into transactions
+// with the default BatchWriter thresholds, until the channel is closed or ctx is done.
+//
+// Deprecated: errors are discarded; use NewBatchWriter, which reports the result of every statement.
 func (db *DB) BatchExec(ctx context.Context, in <-chan string) {
+	w := db.NewBatchWriter(ctx, nil)
+	go func() {
+		for range w.Results() {
+		}
+	}()
+
 	go func() {
+		defer func() {
+			_ = w.Close(context.Background())
+		}()
 		for query := range in {
-			_, _ = db.ExecContext(ctx, query) // Errors can be logged if needed.
-			select {
-			case <-ctx.Done():
+			if err := w.Enqueue(ctx, query); err != nil {
 				return
-			default:
 			}
 		}
 	}()
 }
 
-// selectDB returns a database for query execution: slave (round-robin) or master.
-func (db *DB) selectDB() *sql.DB {
-	if len(db.Slaves) > 0 {
-		// Select a slave using balancer.
-		return db.Slaves[db.balancer.index()]
+// selectDB returns a database for query execution and the index of the chosen slave
+// (-1 for the master): a healthy slave chosen by the balancer, or the master if the context
+// is marked with WithMaster, the query is a write statement, the context made a write within
+// the read-your-writes window (unless marked with WithReplica), there are no slaves or all of them are down.
+func (db *DB) selectDB(ctx context.Context, write bool) (*sql.DB, int) {
+	r := routeFrom(ctx)
+	if r == routeMaster || write {
+		return db.Master, -1
+	}
+
+	if r != routeReplica && db.readYourWritesWindow > 0 && wroteWithin(ctx, db.readYourWritesWindow) {
+		return db.Master, -1
+	}
+
+	if idx, ok := db.balancer.pick(nil); ok {
+		return db.Slaves[idx], idx
 	}
 
-	return db.Master
+	return db.Master, -1
 }
 
 // BeginTx starts a transaction on the master database.
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-	return db.Master.BeginTx(ctx, opts)
+	return breakerDo(db.breaker, func() (*sql.Tx, error) {
+		return db.Master.BeginTx(ctx, opts)
+	})
 }
 
 // BeginTxWithRetry starts a transaction with a retry strategy on the master database.
@@ -177,20 +388,14 @@ func (db *DB) BeginTxWithRetry(
 	strategy retry.Strategy,
 	opts *sql.TxOptions,
 ) (*sql.Tx, error) {
-	var tx *sql.Tx
-	err := retry.Do(func() error {
-		t, e := db.BeginTx(ctx, opts)
-		if e == nil {
-			tx = t
-		}
-		return e
-	}, strategy)
-	return tx, err
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (*sql.Tx, error) {
+		return db.BeginTx(ctx, opts)
+	})
 }
 
 // WithTx executes a function within a transaction on the master database.
 func (db *DB) WithTx(ctx context.Context, fn func(*sql.Tx) error) error {
-	tx, err := db.Master.BeginTx(ctx, nil)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -200,7 +405,12 @@ func (db *DB) WithTx(ctx context.Context, fn func(*sql.Tx) error) error {
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	markWrite(ctx)
+
+	return nil
 }
 
 // WithTxWithRetry executes a function within a transaction on the master database with retry strategy.
@@ -210,7 +420,7 @@ func (db *DB) WithTxWithRetry(
 	fn func(*sql.Tx) error,
 ) error {
 	err := retry.DoContext(ctx, strategy, func() error {
-		tx, e := db.Master.BeginTx(ctx, nil)
+		tx, e := db.BeginTx(ctx, nil)
 		if e != nil {
 			return e
 		}
@@ -223,9 +433,46 @@ func (db *DB) WithTxWithRetry(
 
 		return tx.Commit()
 	})
+	if err == nil {
+		markWrite(ctx)
+	}
 	return err
 }
 
+// breakerDo runs fn through the circuit breaker. Errors caused by the statement itself
+// are returned to the caller but recorded as successes, so that a burst of duplicate keys
+// or typos in one query cannot open the breaker for the whole database.
+func breakerDo[T any](b *circuitbreaker.Breaker, fn func() (T, error)) (T, error) {
+	var stmtErr error
+	result, err := circuitbreaker.Do(b, func() (T, error) {
+		result, err := fn()
+		if isStatementError(err) {
+			stmtErr = err
+			return result, nil
+		}
+		return result, err
+	})
+	if stmtErr != nil {
+		return result, stmtErr
+	}
+	return result, err
+}
+
+// isStatementError reports whether err is a data exception (class 22), an integrity
+// constraint violation (class 23) or a syntax error or access rule violation (class 42).
+func isStatementError(err error) bool {
+	e, ok := pgerr.From(err)
+	if !ok {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(e.Code, "22"), strings.HasPrefix(e.Code, "23"), strings.HasPrefix(e.Code, "42"):
+		return true
+	default:
+		return false
+	}
+}
+
 // Array returns an object that can be passed to Scan for []string.
 func Array(a *[]string) any {
 	return pq.Array(a)
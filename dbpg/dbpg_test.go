@@ -0,0 +1,170 @@
+package dbpg_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/circuitbreaker"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+func TestDB_BreakerIgnoresStatementErrors(t *testing.T) {
+	cb, err := circuitbreaker.New("postgres", circuitbreaker.ConsecutiveFailures(2))
+	require.NoError(t, err)
+
+	master := newFakeDB("master")
+	var execErr error
+	master.exec = func(context.Context, string) error { return execErr }
+
+	db, err := dbpg.NewFromConns(master.open(), nil, &dbpg.Options{CircuitBreaker: cb})
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, code := range []pq.ErrorCode{"23505", "42601", "22P02", "23503"} {
+		execErr = &pq.Error{Code: code}
+		_, err = db.ExecContext(ctx, "INSERT INTO users VALUES (1)")
+		require.ErrorIs(t, err, execErr)
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, cb.State())
+
+	execErr = errors.New("connection reset by peer")
+	for range 2 {
+		_, err = db.ExecContext(ctx, "INSERT INTO users VALUES (1)")
+		require.ErrorIs(t, err, execErr)
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, cb.State())
+
+	_, err = db.ExecContext(ctx, "INSERT INTO users VALUES (1)")
+	require.ErrorIs(t, err, circuitbreaker.ErrOpenState)
+}
+
+// queryValue runs a read query and returns the name of the fake database that answered it.
+func queryValue(t *testing.T, ctx context.Context, db *dbpg.DB) string {
+	t.Helper()
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM users")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var name string
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&name))
+	require.NoError(t, rows.Err())
+	return name
+}
+
+func TestDB_HedgedQuery(t *testing.T) {
+	slow, fast := newFakeDB("slow"), newFakeDB("fast")
+	slow.exec = func(ctx context.Context, _ string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), []*sql.DB{slow.open(), fast.open()},
+		&dbpg.Options{HedgeDelay: 10 * time.Millisecond, MaxHedges: 1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The read goes to the slow slave first and is hedged to the fast one.
+	assert.Equal(t, "fast", queryValue(t, context.Background(), db))
+	assert.Len(t, slow.Queries(), 1)
+	assert.Len(t, fast.Queries(), 1)
+}
+
+func TestDB_HedgedQueryUsesBalancerOnce(t *testing.T) {
+	first, second := newFakeDB("first"), newFakeDB("second")
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), []*sql.DB{first.open(), second.open()},
+		&dbpg.Options{HedgeDelay: time.Second, MaxHedges: 1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	var got []string
+	for range 4 {
+		got = append(got, queryValue(t, context.Background(), db))
+	}
+	assert.Equal(t, []string{"first", "second", "first", "second"}, got)
+}
+
+// opaqueCtx hides the standard cancel context of its parent, as custom context types do,
+// so that every context.WithCancel derived from it starts a propagation goroutine.
+type opaqueCtx struct{ context.Context }
+
+func (opaqueCtx) Value(any) any { return nil }
+
+func TestDB_HedgedQueryReleasesContexts(t *testing.T) {
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), []*sql.DB{newFakeDB("a").open(), newFakeDB("b").open()},
+		&dbpg.Options{HedgeDelay: time.Second, MaxHedges: 1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := opaqueCtx{parent}
+
+	queryValue(t, ctx, db)
+	before := runtime.NumGoroutine()
+	for range 500 {
+		queryValue(t, ctx, db)
+	}
+
+	assert.Eventually(t, func() bool { return runtime.NumGoroutine() <= before },
+		time.Second, 10*time.Millisecond)
+}
+
+func TestDB_ReadYourWritesAfterWriteQuery(t *testing.T) {
+	for _, name := range []string{"QueryContext", "QueryRowContext"} {
+		t.Run(name, func(t *testing.T) {
+			db, err := dbpg.NewFromConns(newFakeDB("master").open(), []*sql.DB{newFakeDB("slave").open()},
+				&dbpg.Options{ReadYourWritesWindow: time.Minute})
+			require.NoError(t, err)
+			defer db.Close()
+
+			ctx := dbpg.WithReadYourWrites(context.Background())
+			assert.Equal(t, "slave", queryValue(t, ctx, db))
+
+			var id string
+			const insert = "INSERT INTO users (name) VALUES ($1) RETURNING id"
+			if name == "QueryContext" {
+				rows, err := db.QueryContext(ctx, insert, "bob")
+				require.NoError(t, err)
+				require.NoError(t, rows.Close())
+			} else {
+				require.NoError(t, db.QueryRowContext(ctx, insert, "bob").Scan(&id))
+			}
+
+			assert.Equal(t, "master", queryValue(t, ctx, db))
+			assert.Equal(t, "slave", queryValue(t, context.Background(), db))
+		})
+	}
+}
+
+func TestDB_ReplicationLag(t *testing.T) {
+	stale, fresh := newFakeDB("stale"), newFakeDB("fresh")
+	// Without a streaming WAL receiver and replayed transactions, the lag is unknown.
+	stale.value = func(string) driver.Value { return nil }
+	fresh.value = func(query string) driver.Value {
+		if strings.Contains(query, "pg_last_wal_replay_lsn") {
+			return 0.5
+		}
+		return "fresh"
+	}
+
+	db, err := dbpg.NewFromConns(newFakeDB("master").open(), []*sql.DB{stale.open(), fresh.open()},
+		&dbpg.Options{MaxReplicationLag: time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, 1, db.HealthySlaves())
+	assert.Equal(t, []time.Duration{0, 500 * time.Millisecond}, db.ReplicationLags())
+	assert.Equal(t, "fresh", queryValue(t, context.Background(), db))
+}
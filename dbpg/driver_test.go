@@ -0,0 +1,152 @@
+package dbpg_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// fakeDB is an in-memory database/sql connector. Statements executed in a transaction
+// are recorded in committed only after Commit.
+type fakeDB struct {
+	name string
+	// exec, if set, decides the outcome of every statement and query.
+	exec func(ctx context.Context, query string) error
+	// value, if set, returns the value of the single row of a query instead of name.
+	value func(query string) driver.Value
+
+	mu        sync.Mutex
+	queries   []string
+	committed []string
+	rollbacks int
+}
+
+func newFakeDB(name string) *fakeDB {
+	return &fakeDB{name: name}
+}
+
+// open returns a *sql.DB backed by f.
+func (f *fakeDB) open() *sql.DB {
+	return sql.OpenDB(f)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+// Queries returns all statements and queries received so far.
+func (f *fakeDB) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+// Committed returns the statements of committed transactions.
+func (f *fakeDB) Committed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.committed...)
+}
+
+func (f *fakeDB) run(ctx context.Context, query string) error {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.mu.Unlock()
+
+	if f.exec != nil {
+		return f.exec(ctx, query)
+	}
+	return nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver: use sql.OpenDB")
+}
+
+type fakeConn struct {
+	db *fakeDB
+	tx []string // Statements of the open transaction.
+	in bool
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver: prepare is not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.tx, c.in = nil, true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	c.db.committed = append(c.db.committed, c.tx...)
+	c.db.mu.Unlock()
+	c.tx, c.in = nil, false
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	c.db.rollbacks++
+	c.db.mu.Unlock()
+	c.tx, c.in = nil, false
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.db.run(ctx, query); err != nil {
+		return nil, err
+	}
+	if c.in {
+		c.tx = append(c.tx, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.db.run(ctx, query); err != nil {
+		return nil, err
+	}
+	value := driver.Value(c.db.name)
+	if c.db.value != nil {
+		value = c.db.value(query)
+	}
+	return &fakeRows{values: []driver.Value{value}}, nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	return c.db.run(ctx, "ping")
+}
+
+// fakeRows returns a single row with a single column.
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
@@ -0,0 +1,11 @@
+package dbpg
+
+import "database/sql"
+
+// NewFromConns creates a DB on already opened connections, so that tests can use fake drivers.
+func NewFromConns(master *sql.DB, slaves []*sql.DB, opts *Options) (*DB, error) {
+	return newDB(master, slaves, opts)
+}
+
+// IsWriteStatement exposes isWriteStatement to tests.
+var IsWriteStatement = isWriteStatement
@@ -0,0 +1,129 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stateDB is an in-memory database/sql connector emulating the statements issued
+// by a Migrator: advisory locks, the state table and the migration files themselves.
+type stateDB struct {
+	mu       sync.Mutex
+	applied  map[int64]bool
+	executed []string // Migration statements in execution order.
+}
+
+func newStateDB(applied ...int64) *stateDB {
+	db := &stateDB{applied: make(map[int64]bool)}
+	for _, version := range applied {
+		db.applied[version] = true
+	}
+	return db
+}
+
+func (s *stateDB) open() *sql.DB {
+	return sql.OpenDB(s)
+}
+
+func (s *stateDB) Connect(context.Context) (driver.Conn, error) {
+	return &stateConn{db: s}, nil
+}
+
+func (s *stateDB) Driver() driver.Driver {
+	return stateDriver{}
+}
+
+// Applied returns the applied versions in ascending order.
+func (s *stateDB) Applied() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := make([]int64, 0, len(s.applied))
+	for version := range s.applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// Executed returns the executed migration statements.
+func (s *stateDB) Executed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.executed)
+}
+
+type stateDriver struct{}
+
+func (stateDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("state driver: use sql.OpenDB")
+}
+
+type stateConn struct {
+	db *stateDB
+}
+
+func (c *stateConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("state driver: prepare is not supported")
+}
+
+func (c *stateConn) Close() error { return nil }
+
+func (c *stateConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *stateConn) Commit() error { return nil }
+
+func (c *stateConn) Rollback() error { return nil }
+
+func (c *stateConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "SELECT pg_advisory"), strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		c.db.applied[args[0].Value.(int64)] = true
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		delete(c.db.applied, args[0].Value.(int64))
+	default:
+		c.db.executed = append(c.db.executed, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *stateConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.HasPrefix(query, "SELECT to_regclass") {
+		return &stateRows{columns: []string{"exists"}, rows: [][]driver.Value{{true}}}, nil
+	}
+
+	rows := &stateRows{columns: []string{"version", "applied_at"}}
+	for _, version := range c.db.Applied() {
+		rows.rows = append(rows.rows, []driver.Value{version, time.Unix(0, 0)})
+	}
+	return rows, nil
+}
+
+type stateRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stateRows) Columns() []string { return r.columns }
+
+func (r *stateRows) Close() error { return nil }
+
+func (r *stateRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,429 @@
+// Package migrate applies versioned SQL migrations to PostgreSQL.
+//
+// Migrations are read from the root of an fs.FS (for example, an embed.FS passed through fs.Sub)
+// as <version>_<name>.up.sql and <version>_<name>.down.sql files. Applied versions are tracked
+// in a state table, and a session-level pg_advisory_lock ensures that only one replica
+// migrates at a time. Each migration runs in its own transaction unless its file starts
+// with "-- migrate:no-transaction".
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/wb-go/wbf/logger"
+)
+
+const _defaultTable = "schema_migrations"
+
+// Migration describes a migration version and its state.
+type Migration struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time // Zero if the migration is not applied.
+}
+
+// Migrator applies migrations from a file system to a database.
+type Migrator struct {
+	db      *sql.DB
+	ownDB   bool
+	fsys    fs.FS
+	sources []source
+	logger  logger.Logger
+
+	table      string
+	lockKey    int64
+	lockKeySet bool
+	dryRun     bool
+}
+
+// step is a single migration to apply or roll back.
+type step struct {
+	source source
+	up     bool
+}
+
+// New creates a Migrator for a database/sql connection, e.g. dbpg.DB.Master.
+// Migration files are read and validated immediately.
+func New(db *sql.DB, fsys fs.FS, logger logger.Logger, opts ...Option) (*Migrator, error) {
+	const op = "dbpg.migrate.New"
+
+	m := &Migrator{
+		db:     db,
+		fsys:   fsys,
+		logger: logger,
+		table:  _defaultTable,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: validation: %w", op, err)
+	}
+
+	if !m.lockKeySet {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte("wbf.migrate:" + m.table))
+		m.lockKey = int64(h.Sum64()) //nolint:gosec // Any 64-bit value is a valid lock key.
+	}
+
+	sources, err := readSources(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	m.sources = sources
+
+	return m, nil
+}
+
+// NewFromPool creates a Migrator for a pgx pool, e.g. pgxdriver.Postgres.Pool.
+// Call Close to release the database/sql adapter; the pool itself stays open.
+func NewFromPool(pool *pgxpool.Pool, fsys fs.FS, logger logger.Logger, opts ...Option) (*Migrator, error) {
+	db := stdlib.OpenDBFromPool(pool)
+
+	m, err := New(db, fsys, logger, opts...)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	m.ownDB = true
+
+	return m, nil
+}
+
+// Close releases the database/sql adapter created by NewFromPool.
+// It does not close a database passed to New.
+func (m *Migrator) Close() error {
+	if m.ownDB {
+		return m.db.Close()
+	}
+	return nil
+}
+
+// Up applies all pending migrations. Applied versions without migration files, e.g. applied
+// by a newer release during a rolling deploy, are left in place and logged.
+func (m *Migrator) Up(ctx context.Context) error {
+	const op = "dbpg.migrate.Up"
+
+	var target int64
+	if len(m.sources) > 0 {
+		target = m.sources[len(m.sources)-1].version
+	}
+
+	if err := m.migrateTo(ctx, target, false); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// To migrates the database to the target version: pending migrations up to and including
+// the target are applied, and applied migrations above it are rolled back.
+// Version 0 rolls back all migrations.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	const op = "dbpg.migrate.To"
+
+	if version != 0 && m.find(version) < 0 {
+		return fmt.Errorf("%s: %w: %d", op, ErrUnknownVersion, version)
+	}
+
+	if err := m.migrateTo(ctx, version, true); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied migration, if any.
+func (m *Migrator) Down(ctx context.Context) error {
+	const op = "dbpg.migrate.Down"
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return nil
+		}
+
+		latest := slices.Max(slices.Collect(maps.Keys(applied)))
+		steps, err := m.downSteps([]int64{latest})
+		if err != nil {
+			return err
+		}
+		return m.run(ctx, conn, steps)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Status returns all known migrations with their state, ordered by version.
+// Applied versions without migration files are included with an empty name.
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	const op = "dbpg.migrate.Status"
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: acquire connection: %w", op, err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	migrations := make([]Migration, 0, len(m.sources))
+	for _, src := range m.sources {
+		at, ok := applied[src.version]
+		migrations = append(migrations, Migration{Version: src.version, Name: src.name, Applied: ok, AppliedAt: at})
+		delete(applied, src.version)
+	}
+	for version, at := range applied {
+		migrations = append(migrations, Migration{Version: version, Applied: true, AppliedAt: at})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// migrateTo applies pending migrations up to target under the advisory lock.
+// With rollback set, applied migrations above target are rolled back;
+// otherwise they are skipped, and those without migration files are logged.
+func (m *Migrator) migrateTo(ctx context.Context, target int64, rollback bool) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var down []int64
+		for version := range applied {
+			if version <= target {
+				continue
+			}
+			if rollback {
+				down = append(down, version)
+			} else if m.find(version) < 0 {
+				m.log(ctx, logger.WarnLevel, "applied migration is unknown to this build, skipping",
+					logger.Int64("version", version))
+			}
+		}
+		slices.SortFunc(down, func(a, b int64) int { return cmp.Compare(b, a) })
+
+		steps, err := m.downSteps(down)
+		if err != nil {
+			return err
+		}
+		for _, src := range m.sources {
+			if _, ok := applied[src.version]; !ok && src.version <= target {
+				steps = append(steps, step{source: src, up: true})
+			}
+		}
+
+		if len(steps) == 0 {
+			m.log(ctx, logger.InfoLevel, "database schema is up to date", logger.Int64("version", target))
+			return nil
+		}
+		return m.run(ctx, conn, steps)
+	})
+}
+
+// downSteps returns rollback steps for the given versions, which must all have down files.
+func (m *Migrator) downSteps(versions []int64) ([]step, error) {
+	steps := make([]step, 0, len(versions))
+	for _, version := range versions {
+		idx := m.find(version)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: applied version %d", ErrUnknownVersion, version)
+		}
+		if m.sources[idx].down == "" {
+			return nil, fmt.Errorf("%w: %d", ErrMissingDown, version)
+		}
+		steps = append(steps, step{source: m.sources[idx]})
+	}
+	return steps, nil
+}
+
+// run executes the steps in order, stopping at the first failure.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, steps []step) error {
+	for _, s := range steps {
+		if err := m.execute(ctx, conn, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute applies or rolls back a single migration and records the new state.
+func (m *Migrator) execute(ctx context.Context, conn *sql.Conn, s step) error {
+	direction, file := "up", s.source.up
+	record, args := "INSERT INTO "+m.table+" (version, name) VALUES ($1, $2)", []any{s.source.version, s.source.name}
+	if !s.up {
+		direction, file = "down", s.source.down
+		record, args = "DELETE FROM "+m.table+" WHERE version = $1", []any{s.source.version}
+	}
+
+	attrs := []logger.Attr{
+		logger.Int64("version", s.source.version),
+		logger.String("name", s.source.name),
+		logger.String("direction", direction),
+	}
+
+	query, inTx, err := readFile(m.fsys, file)
+	if err != nil {
+		return err
+	}
+
+	if m.dryRun {
+		m.log(ctx, logger.InfoLevel, "migration planned (dry run)", attrs...)
+		return nil
+	}
+
+	start := time.Now()
+	if inTx {
+		err = execInTx(ctx, conn, query, record, args)
+	} else {
+		err = execDirect(ctx, conn, query, record, args)
+	}
+	if err != nil {
+		m.log(ctx, logger.ErrorLevel, "migration failed", append(attrs, logger.Any("error", err))...)
+		return fmt.Errorf("migration %d_%s (%s): %w", s.source.version, s.source.name, direction, err)
+	}
+
+	m.log(ctx, logger.InfoLevel, "migration applied", append(attrs, logger.Duration("duration", time.Since(start)))...)
+	return nil
+}
+
+// execInTx runs the migration and its state change in one transaction.
+func execInTx(ctx context.Context, conn *sql.Conn, query, record string, args []any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record state: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// execDirect runs a migration that cannot be executed inside a transaction.
+func execDirect(ctx context.Context, conn *sql.Conn, query, record string, args []any) error {
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("record state: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+// The state table is created first unless in dry-run mode.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockKey); err != nil {
+		return fmt.Errorf("acquire advisory lock: %w", err)
+	}
+	defer func() {
+		// The lock is released with the session if the unlock fails.
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", m.lockKey)
+	}()
+
+	if !m.dryRun {
+		if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.table+` (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+			return fmt.Errorf("create state table: %w", err)
+		}
+	}
+
+	return fn(conn)
+}
+
+// applied returns applied versions with their application time.
+// A missing state table means no migrations are applied.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check state table: %w", err)
+	}
+	applied := make(map[int64]time.Time)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("read state: %w", err)
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+
+	return applied, nil
+}
+
+// find returns the index of the source with the version, or -1.
+func (m *Migrator) find(version int64) int {
+	idx, ok := slices.BinarySearchFunc(m.sources, version, func(s source, v int64) int {
+		return cmp.Compare(s.version, v)
+	})
+	if !ok {
+		return -1
+	}
+	return idx
+}
+
+// log writes a record if a logger is configured.
+func (m *Migrator) log(ctx context.Context, level logger.Level, msg string, attrs ...logger.Attr) {
+	if m.logger != nil {
+		m.logger.LogAttrs(ctx, level, msg, append(attrs, logger.String("table", m.table))...)
+	}
+}
@@ -0,0 +1,115 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg/migrate"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	// sql.Open does not connect, so source validation runs without a database.
+	db, err := sql.Open("postgres", "postgres://localhost/migrate_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestNew_ValidatesSources(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want error
+	}{
+		{
+			name: "valid",
+			fsys: fstest.MapFS{
+				"0001_create_users.up.sql":   file,
+				"0001_create_users.down.sql": file,
+				"0002_add_email.up.sql":      file,
+				"README.md":                  file,
+			},
+		},
+		{
+			name: "invalid file name",
+			fsys: fstest.MapFS{"create_users.up.sql": file},
+			want: migrate.ErrInvalidFileName,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{"1_create_users.up.sql": file, "01_create_orders.up.sql": file},
+			want: migrate.ErrDuplicateVersion,
+		},
+		{
+			name: "missing up",
+			fsys: fstest.MapFS{"1_create_users.down.sql": file},
+			want: migrate.ErrMissingUp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrate.New(openDB(t), tt.fsys, nil)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestNew_ValidatesTable(t *testing.T) {
+	_, err := migrate.New(openDB(t), fstest.MapFS{}, nil, migrate.Table("public.schema_migrations"))
+	require.NoError(t, err)
+
+	_, err = migrate.New(openDB(t), fstest.MapFS{}, nil, migrate.Table("migrations; DROP TABLE users"))
+	require.ErrorIs(t, err, migrate.ErrInvalidTable)
+}
+
+func migrationFS(versions ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, version := range versions {
+		fsys[version+"_step.up.sql"] = &fstest.MapFile{Data: []byte("UP " + version)}
+		fsys[version+"_step.down.sql"] = &fstest.MapFile{Data: []byte("DOWN " + version)}
+	}
+	return fsys
+}
+
+func TestUp_SkipsNewerUnknownVersions(t *testing.T) {
+	// A newer release has already applied version 3, which this build does not know.
+	state := newStateDB(1, 3)
+
+	m, err := migrate.New(state.open(), migrationFS("1", "2"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up(context.Background()))
+	assert.Equal(t, []string{"UP 2"}, state.Executed())
+	assert.Equal(t, []int64{1, 2, 3}, state.Applied())
+}
+
+func TestTo_RollsBackAboveTarget(t *testing.T) {
+	state := newStateDB(1, 2, 3)
+
+	m, err := migrate.New(state.open(), migrationFS("1", "2", "3"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, m.To(context.Background(), 1))
+	assert.Equal(t, []string{"DOWN 3", "DOWN 2"}, state.Executed())
+	assert.Equal(t, []int64{1}, state.Applied())
+
+	// To cannot roll back a version without migration files.
+	state = newStateDB(1, 3)
+	m, err = migrate.New(state.open(), migrationFS("1", "2"), nil)
+	require.NoError(t, err)
+	require.ErrorIs(t, m.To(context.Background(), 2), migrate.ErrUnknownVersion)
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	// ErrNilDB is returned when New is called without a database.
+	ErrNilDB = errors.New("database must not be nil")
+	// ErrNilFS is returned when New is called without a migrations file system.
+	ErrNilFS = errors.New("migrations file system must not be nil")
+	// ErrInvalidTable is returned when Table is not a valid, optionally schema-qualified, identifier.
+	ErrInvalidTable = errors.New("invalid table: must be an identifier or schema.identifier")
+	// ErrInvalidFileName is returned when a .sql file does not match <version>_<name>.(up|down).sql.
+	ErrInvalidFileName = errors.New("invalid migration file name: must be <version>_<name>.(up|down).sql")
+	// ErrDuplicateVersion is returned when two migrations share a version and direction.
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	// ErrMissingUp is returned when a version has a down migration but no up migration.
+	ErrMissingUp = errors.New("missing up migration")
+	// ErrMissingDown is returned when a migration has to be rolled back but has no down file.
+	ErrMissingDown = errors.New("missing down migration")
+	// ErrUnknownVersion is returned when the target version or an applied version
+	// has no migration files.
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// identifierPattern matches an identifier optionally qualified with a schema.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Option represents a functional configuration option for the Migrator.
+type Option func(*Migrator)
+
+// Table sets the table storing applied versions (schema_migrations by default).
+// The name may be qualified with a schema.
+func Table(name string) Option {
+	return func(m *Migrator) {
+		m.table = name
+	}
+}
+
+// LockKey sets the pg_advisory_lock key that serializes migrations across replicas.
+// By default the key is derived from the table name.
+func LockKey(key int64) Option {
+	return func(m *Migrator) {
+		m.lockKey = key
+		m.lockKeySet = true
+	}
+}
+
+// DryRun makes the Migrator log the planned steps without executing them
+// or creating the state table.
+func DryRun(enabled bool) Option {
+	return func(m *Migrator) {
+		m.dryRun = enabled
+	}
+}
+
+// validate checks that all Migrator configuration parameters are valid.
+func (m *Migrator) validate() error {
+	if m.db == nil {
+		return ErrNilDB
+	}
+
+	if m.fsys == nil {
+		return ErrNilFS
+	}
+
+	if !identifierPattern.MatchString(m.table) {
+		return ErrInvalidTable
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// fileNamePattern matches migration file names like 0001_create_users.up.sql.
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// noTransactionDirective at the start of a file disables the wrapping transaction,
+// e.g. for CREATE INDEX CONCURRENTLY.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// source is a migration version with its files.
+type source struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+// readSources reads migration files from the root of fsys, sorted by version.
+// Files without the .sql extension are ignored.
+func readSources(fsys fs.FS) ([]source, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	byVersion := make(map[int64]*source)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		src, ok := byVersion[version]
+		if !ok {
+			src = &source{version: version, name: match[2]}
+			byVersion[version] = src
+		}
+
+		file := &src.up
+		if match[3] == "down" {
+			file = &src.down
+		}
+		if *file != "" || src.name != match[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+		}
+		*file = entry.Name()
+	}
+
+	sources := make([]source, 0, len(byVersion))
+	for _, src := range byVersion {
+		if src.up == "" {
+			return nil, fmt.Errorf("%w: %d", ErrMissingUp, src.version)
+		}
+		sources = append(sources, *src)
+	}
+	slices.SortFunc(sources, func(a, b source) int {
+		return cmp.Compare(a.version, b.version)
+	})
+
+	return sources, nil
+}
+
+// readFile returns the contents of a migration file and whether it must run in a transaction.
+func readFile(fsys fs.FS, name string) (string, bool, error) {
+	body, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	query := string(body)
+	return query, !strings.HasPrefix(strings.TrimSpace(query), noTransactionDirective), nil
+}
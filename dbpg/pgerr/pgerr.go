@@ -0,0 +1,268 @@
+// Package pgerr classifies PostgreSQL errors returned by lib/pq (dbpg) and pgx v5 (pgxdriver)
+// into driver-independent typed errors.
+//
+// Classify wraps a driver error into an *Error carrying its Kind, SQLSTATE code, constraint and
+// columns. The wrapped error matches the Kind sentinel with errors.Is and still unwraps to the
+// driver error:
+//
+//	if errors.Is(pgerr.Classify(err), pgerr.ErrUniqueViolation) { ... }
+//
+//	var pgErr *pgerr.Error
+//	if errors.As(pgerr.Classify(err), &pgErr) {
+//		fmt.Println(pgErr.Constraint, pgErr.Columns)
+//	}
+package pgerr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// Kind is the class of a PostgreSQL error.
+type Kind int
+
+const (
+	// KindOther is a PostgreSQL error of any other class.
+	KindOther Kind = iota
+	// KindUniqueViolation is a unique constraint violation (23505).
+	KindUniqueViolation
+	// KindForeignKeyViolation is a foreign key violation (23503).
+	KindForeignKeyViolation
+	// KindNotNullViolation is a not-null constraint violation (23502).
+	KindNotNullViolation
+	// KindCheckViolation is a check constraint violation (23514).
+	KindCheckViolation
+	// KindSerializationFailure is a serialization failure of a concurrent transaction (40001).
+	KindSerializationFailure
+	// KindDeadlock is a detected deadlock (40P01).
+	KindDeadlock
+	// KindQueryCanceled is a query canceled by statement_timeout or a cancel request (57014).
+	KindQueryCanceled
+	// KindLockNotAvailable is a lock not acquired within lock_timeout or with NOWAIT (55P03).
+	KindLockNotAvailable
+	// KindConnectionException is a lost or failed connection (class 08, server shutdown 57P01-57P03).
+	KindConnectionException
+)
+
+// Sentinel errors matched by an *Error of the corresponding Kind.
+var (
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlock             = errors.New("deadlock detected")
+	ErrQueryCanceled        = errors.New("query canceled")
+	ErrLockNotAvailable     = errors.New("lock not available")
+	ErrConnectionException  = errors.New("connection exception")
+)
+
+// sentinels maps kinds to their sentinel errors.
+var sentinels = map[Kind]error{
+	KindUniqueViolation:      ErrUniqueViolation,
+	KindForeignKeyViolation:  ErrForeignKeyViolation,
+	KindNotNullViolation:     ErrNotNullViolation,
+	KindCheckViolation:       ErrCheckViolation,
+	KindSerializationFailure: ErrSerializationFailure,
+	KindDeadlock:             ErrDeadlock,
+	KindQueryCanceled:        ErrQueryCanceled,
+	KindLockNotAvailable:     ErrLockNotAvailable,
+	KindConnectionException:  ErrConnectionException,
+}
+
+// String returns the kind description.
+func (k Kind) String() string {
+	if err, ok := sentinels[k]; ok {
+		return err.Error()
+	}
+	return "database error"
+}
+
+// keyPattern extracts the columns from details like `Key (a, b)=(1, 2) already exists.`.
+var keyPattern = regexp.MustCompile(`^Key \((.+?)\)=\(`)
+
+// Error is a classified PostgreSQL error.
+type Error struct {
+	Kind Kind
+	// Code is the SQLSTATE code; empty for client-side connection errors.
+	Code       string
+	Message    string
+	Detail     string
+	Table      string
+	Constraint string
+	// Columns are the columns of the violated key (unique and foreign key violations)
+	// or the column of a not-null violation.
+	Columns []string
+
+	err error
+}
+
+// Error returns the driver error message.
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the driver error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the sentinel error of the error kind.
+func (e *Error) Is(target error) bool {
+	sentinel, ok := sentinels[e.Kind]
+	return ok && sentinel == target
+}
+
+// Retryable reports whether the failed transaction may succeed if retried.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindSerializationFailure, KindDeadlock, KindConnectionException:
+		return true
+	default:
+		return false
+	}
+}
+
+// Classify wraps a lib/pq or pgx v5 error into an *Error. Errors that are neither PostgreSQL
+// nor connection errors, including nil and context errors, are returned unchanged.
+// An error that already contains an *Error is returned unchanged as well.
+func Classify(err error) error {
+	var existing *Error
+	if errors.As(err, &existing) {
+		return err
+	}
+
+	if e, ok := From(err); ok {
+		return e
+	}
+	return err
+}
+
+// From returns the classified PostgreSQL or connection error contained in err.
+func From(err error) (*Error, bool) {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified, true
+	}
+
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		return newError(err, pgxErr.Code, pgxErr.Message, pgxErr.Detail,
+			pgxErr.TableName, pgxErr.ConstraintName, pgxErr.ColumnName), true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return newError(err, string(pqErr.Code), pqErr.Message, pqErr.Detail,
+			pqErr.Table, pqErr.Constraint, pqErr.Column), true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || errors.Is(err, driver.ErrBadConn) {
+		return &Error{Kind: KindConnectionException, Message: err.Error(), err: err}, true
+	}
+
+	return nil, false
+}
+
+// newError builds an *Error from the fields of a driver error.
+func newError(err error, code, message, detail, table, constraint, column string) *Error {
+	e := &Error{
+		Kind:       kindOf(code),
+		Code:       code,
+		Message:    message,
+		Detail:     detail,
+		Table:      table,
+		Constraint: constraint,
+		err:        err,
+	}
+
+	if m := keyPattern.FindStringSubmatch(detail); m != nil {
+		e.Columns = strings.Split(m[1], ", ")
+	} else if column != "" {
+		e.Columns = []string{column}
+	}
+	return e
+}
+
+// kindOf maps a SQLSTATE code to its kind.
+func kindOf(code string) Kind {
+	switch code {
+	case "23505":
+		return KindUniqueViolation
+	case "23503":
+		return KindForeignKeyViolation
+	case "23502":
+		return KindNotNullViolation
+	case "23514":
+		return KindCheckViolation
+	case "40001":
+		return KindSerializationFailure
+	case "40P01":
+		return KindDeadlock
+	case "57014":
+		return KindQueryCanceled
+	case "55P03":
+		return KindLockNotAvailable
+	case "57P01", "57P02", "57P03":
+		return KindConnectionException
+	}
+
+	if strings.HasPrefix(code, "08") {
+		return KindConnectionException
+	}
+	return KindOther
+}
+
+// IsRetryable reports whether err is a serialization failure, deadlock or connection error.
+// It can be used as retry.Strategy.RetryIf.
+func IsRetryable(err error) bool {
+	e, ok := From(err)
+	return ok && e.Retryable()
+}
+
+// HTTPStatus returns the HTTP status code for a database error:
+//   - 409 Conflict for unique violations and deletes of rows still referenced by a foreign key;
+//   - 422 Unprocessable Entity for other foreign key, not-null and check violations;
+//   - 503 Service Unavailable for serialization failures, deadlocks, lock timeouts and connection errors;
+//   - 504 Gateway Timeout for canceled queries and context deadlines;
+//   - 500 Internal Server Error otherwise.
+func HTTPStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+
+	e, ok := From(err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+
+	switch e.Kind {
+	case KindUniqueViolation:
+		return http.StatusConflict
+	case KindForeignKeyViolation:
+		if strings.Contains(e.Detail, "is still referenced") {
+			return http.StatusConflict
+		}
+		return http.StatusUnprocessableEntity
+	case KindNotNullViolation, KindCheckViolation:
+		return http.StatusUnprocessableEntity
+	case KindSerializationFailure, KindDeadlock, KindLockNotAvailable, KindConnectionException:
+		return http.StatusServiceUnavailable
+	case KindQueryCanceled:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,84 @@
+package pgerr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg/pgerr"
+)
+
+func TestClassify_Pgx(t *testing.T) {
+	driverErr := &pgconn.PgError{
+		Code:           "23505",
+		Message:        `duplicate key value violates unique constraint "users_email_key"`,
+		Detail:         "Key (tenant_id, email)=(1, a@b.c) already exists.",
+		TableName:      "users",
+		ConstraintName: "users_email_key",
+	}
+	err := pgerr.Classify(fmt.Errorf("insert user: %w", driverErr))
+
+	require.ErrorIs(t, err, pgerr.ErrUniqueViolation)
+	assert.NotErrorIs(t, err, pgerr.ErrForeignKeyViolation)
+
+	var pgErr *pgerr.Error
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, pgerr.KindUniqueViolation, pgErr.Kind)
+	assert.Equal(t, "users_email_key", pgErr.Constraint)
+	assert.Equal(t, []string{"tenant_id", "email"}, pgErr.Columns)
+
+	var original *pgconn.PgError
+	require.ErrorAs(t, err, &original)
+	assert.Equal(t, http.StatusConflict, pgerr.HTTPStatus(err))
+}
+
+func TestClassify_Pq(t *testing.T) {
+	err := pgerr.Classify(&pq.Error{Code: "23502", Column: "name", Table: "users"})
+
+	require.ErrorIs(t, err, pgerr.ErrNotNullViolation)
+	var pgErr *pgerr.Error
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, []string{"name"}, pgErr.Columns)
+	assert.Equal(t, http.StatusUnprocessableEntity, pgerr.HTTPStatus(err))
+
+	assert.ErrorIs(t, pgerr.Classify(&pq.Error{Code: "40001"}), pgerr.ErrSerializationFailure)
+}
+
+func TestClassify_Unchanged(t *testing.T) {
+	plain := errors.New("boom")
+
+	assert.Same(t, plain, pgerr.Classify(plain))
+	assert.NoError(t, pgerr.Classify(nil))
+	assert.Equal(t, context.Canceled, pgerr.Classify(context.Canceled))
+
+	classified := pgerr.Classify(&pgconn.PgError{Code: "40P01"})
+	assert.Same(t, classified, pgerr.Classify(classified))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, pgerr.IsRetryable(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, pgerr.IsRetryable(&pq.Error{Code: "40P01"}))
+	assert.True(t, pgerr.IsRetryable(&pgconn.PgError{Code: "08006"}))
+	assert.False(t, pgerr.IsRetryable(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, pgerr.IsRetryable(context.DeadlineExceeded))
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusConflict, pgerr.HTTPStatus(&pgconn.PgError{
+		Code:   "23503",
+		Detail: `Key (id)=(1) is still referenced from table "orders".`,
+	}))
+	assert.Equal(t, http.StatusUnprocessableEntity, pgerr.HTTPStatus(&pgconn.PgError{
+		Code:   "23503",
+		Detail: `Key (user_id)=(1) is not present in table "users".`,
+	}))
+	assert.Equal(t, http.StatusServiceUnavailable, pgerr.HTTPStatus(&pgconn.PgError{Code: "40001"}))
+	assert.Equal(t, http.StatusGatewayTimeout, pgerr.HTTPStatus(&pgconn.PgError{Code: "57014"}))
+	assert.Equal(t, http.StatusInternalServerError, pgerr.HTTPStatus(errors.New("boom")))
+}
@@ -2,9 +2,12 @@ package pgxdriver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // BatchInsert executes the same SQL statement multiple times with different parameters
@@ -35,3 +38,258 @@ func BatchInsert(ctx context.Context, qe QueryExecuter, sql string, rows [][]any
 
 	return nil
 }
+
+const _defaultBatchSize = 1000
+
+var (
+	// ErrInvalidBatchSize is returned when BatchSize < 0.
+	ErrInvalidBatchSize = errors.New("invalid batch size: must be >= 0")
+	// ErrBatchAborted is set on statements that were not applied because another statement
+	// of the same batch failed, or because an earlier batch aborted the transaction.
+	ErrBatchAborted = errors.New("batch aborted")
+)
+
+// Batch collects heterogeneous statements for ExecBatch. The zero value is ready to use.
+type Batch struct {
+	items []*BatchItem
+}
+
+// BatchItem is a statement queued in a Batch. By default its result is read as with Exec;
+// use Exec, QueryRow or Query to set a callback receiving the result.
+type BatchItem struct {
+	sql  string
+	args []any
+
+	exec     func(pgconn.CommandTag) error
+	queryRow func(pgx.Row) error
+	query    func(pgx.Rows) error
+}
+
+// BatchItemResult is the outcome of a single Batch statement.
+type BatchItemResult struct {
+	// Index is the position of the statement in the Batch.
+	Index int
+	SQL   string
+	Args  []any
+	// CommandTag is set for statements read as with Exec.
+	CommandTag pgconn.CommandTag
+	// Err is the statement error, or ErrBatchAborted if the statement was rolled back
+	// because of another statement. A nil Err means the statement was applied.
+	Err error
+	// CallbackErr is the error of the item callback. The statement itself was applied
+	// unless Err is set, so it must not be retried because of CallbackErr alone.
+	CallbackErr error
+}
+
+// BatchItemResults holds the outcomes of all Batch statements in queue order.
+type BatchItemResults []BatchItemResult
+
+// BatchOption represents a functional configuration option for ExecBatch.
+type BatchOption func(*batchConfig)
+
+// batchConfig holds ExecBatch settings.
+type batchConfig struct {
+	size int
+}
+
+// BatchSize sets the maximum number of statements sent in one round trip (1000 by default).
+func BatchSize(size int) BatchOption {
+	return func(c *batchConfig) {
+		c.size = size
+	}
+}
+
+// Queue adds a statement to the batch.
+func (b *Batch) Queue(sql string, args ...any) *BatchItem {
+	item := &BatchItem{sql: sql, args: args}
+	b.items = append(b.items, item)
+	return item
+}
+
+// Len returns the number of queued statements.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Exec sets a callback receiving the command tag of the statement.
+func (i *BatchItem) Exec(fn func(tag pgconn.CommandTag) error) {
+	i.exec, i.queryRow, i.query = fn, nil, nil
+}
+
+// QueryRow sets a callback reading the single result row. The callback must call Scan.
+func (i *BatchItem) QueryRow(fn func(row pgx.Row) error) {
+	i.exec, i.queryRow, i.query = nil, fn, nil
+}
+
+// Query sets a callback reading the result rows. The rows are closed after the callback returns.
+func (i *BatchItem) Query(fn func(rows pgx.Rows) error) {
+	i.exec, i.queryRow, i.query = nil, nil, fn
+}
+
+// ExecBatch sends the batch statements with any QueryExecuter, splitting them into batches
+// of BatchSize statements, runs the item callbacks and reports the outcome of every statement.
+//
+// Each batch runs in an implicit transaction: if a statement fails, the other statements of its
+// batch are rolled back and reported with ErrBatchAborted, so they can be retried. Outside a
+// transaction the remaining batches are still sent; within a *TxQueryExecuter the transaction
+// is aborted and the remaining statements are reported with ErrBatchAborted as well.
+// A callback error does not undo its statement: it is reported in CallbackErr, and Err stays nil
+// unless the statement was rolled back because of another statement.
+// The returned error is the first statement error, or else the first callback error;
+// the results are always complete.
+func ExecBatch(ctx context.Context, qe QueryExecuter, b *Batch, opts ...BatchOption) (BatchItemResults, error) {
+	const op = "dbpg.pgx-driver.ExecBatch"
+
+	cfg := batchConfig{size: _defaultBatchSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.size < 0 {
+		return nil, fmt.Errorf("%s: validation: %w", op, ErrInvalidBatchSize)
+	}
+	if cfg.size == 0 {
+		cfg.size = _defaultBatchSize
+	}
+
+	results := make(BatchItemResults, len(b.items))
+	for i, item := range b.items {
+		results[i] = BatchItemResult{Index: i, SQL: item.sql, Args: item.args}
+	}
+
+	_, inTx := qe.(*TxQueryExecuter)
+	var txErr error
+	for start := 0; start < len(b.items); start += cfg.size {
+		chunk := results[start:min(start+cfg.size, len(b.items))]
+		if txErr != nil {
+			for i := range chunk {
+				chunk[i].Err = fmt.Errorf("%w: %w", ErrBatchAborted, txErr)
+			}
+			continue
+		}
+
+		if err := sendChunk(ctx, qe, b.items[start:start+len(chunk)], chunk); err != nil && inTx {
+			txErr = err
+		}
+	}
+
+	if err := results.Err(); err != nil {
+		return results, fmt.Errorf("%s: %w", op, err)
+	}
+	return results, nil
+}
+
+// sendChunk sends one batch and fills in the results of its items. It returns the batch
+// error if the batch was aborted.
+func sendChunk(ctx context.Context, qe QueryExecuter, items []*BatchItem, results []BatchItemResult) error {
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		batch.Queue(item.sql, item.args...)
+	}
+
+	br := qe.SendBatch(ctx, batch)
+	for i, item := range items {
+		results[i].CommandTag, results[i].Err = item.read(br)
+	}
+
+	batchErr := br.Close()
+	if batchErr == nil {
+		// Every statement was applied, so the item errors come from callbacks.
+		for i := range results {
+			results[i].CallbackErr, results[i].Err = results[i].Err, nil
+		}
+		return nil
+	}
+
+	// The failed statement reports the batch error; callback errors of other statements may precede it.
+	failed := slices.IndexFunc(results, func(r BatchItemResult) bool {
+		return errors.Is(r.Err, batchErr)
+	})
+	if failed < 0 {
+		failed = slices.IndexFunc(results, func(r BatchItemResult) bool {
+			return r.Err != nil
+		})
+	}
+	if failed < 0 {
+		// The batch failed after all results were read, e.g. on connection loss.
+		for i := range results {
+			results[i].Err = batchErr
+		}
+		return batchErr
+	}
+
+	for i := range results {
+		if i != failed {
+			results[i].CallbackErr = results[i].Err
+			results[i].Err = fmt.Errorf("%w: %w", ErrBatchAborted, batchErr)
+		}
+	}
+	return batchErr
+}
+
+// read reads the item result from the batch and runs its callback.
+func (i *BatchItem) read(br pgx.BatchResults) (pgconn.CommandTag, error) {
+	switch {
+	case i.queryRow != nil:
+		return pgconn.CommandTag{}, i.queryRow(br.QueryRow())
+	case i.query != nil:
+		rows, err := br.Query()
+		if err != nil {
+			return pgconn.CommandTag{}, err
+		}
+		defer rows.Close()
+
+		if err := i.query(rows); err != nil {
+			return pgconn.CommandTag{}, err
+		}
+		rows.Close()
+		return rows.CommandTag(), rows.Err()
+	default:
+		tag, err := br.Exec()
+		if err != nil {
+			return tag, err
+		}
+		if i.exec != nil {
+			err = i.exec(tag)
+		}
+		return tag, err
+	}
+}
+
+// Failed returns the results of statements that failed or were aborted, i.e. were not applied.
+// Statements with only a CallbackErr are not included.
+func (r BatchItemResults) Failed() BatchItemResults {
+	var failed BatchItemResults
+	for _, res := range r {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns the first statement error, excluding aborted statements if possible,
+// or else the first callback error, annotated with the statement index.
+func (r BatchItemResults) Err() error {
+	first, callback := -1, -1
+	for i, res := range r {
+		if res.Err == nil {
+			if res.CallbackErr != nil && callback < 0 {
+				callback = i
+			}
+			continue
+		}
+		if !errors.Is(res.Err, ErrBatchAborted) {
+			return fmt.Errorf("statement at index %d: %w", res.Index, res.Err)
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first >= 0 {
+		return fmt.Errorf("statement at index %d: %w", r[first].Index, r[first].Err)
+	}
+	if callback >= 0 {
+		return fmt.Errorf("statement at index %d: callback: %w", r[callback].Index, r[callback].CallbackErr)
+	}
+	return nil
+}
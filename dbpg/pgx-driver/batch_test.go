@@ -0,0 +1,122 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// batchExecuter fails statements whose SQL is listed in fail, aborting their batch as pgx does.
+type batchExecuter struct {
+	pgxdriver.QueryExecuter
+
+	fail    map[string]error
+	batches []int
+}
+
+func (e *batchExecuter) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	e.batches = append(e.batches, b.Len())
+	return &fakeBatchResults{queries: b.QueuedQueries, fail: e.fail}
+}
+
+type fakeBatchResults struct {
+	pgx.BatchResults
+
+	queries []*pgx.QueuedQuery
+	fail    map[string]error
+	idx     int
+	err     error
+}
+
+func (r *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	if r.err != nil {
+		return pgconn.CommandTag{}, r.err
+	}
+	q := r.queries[r.idx]
+	r.idx++
+	if err := r.fail[q.SQL]; err != nil {
+		r.err = err
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (r *fakeBatchResults) Close() error {
+	return r.err
+}
+
+func TestExecBatch_Results(t *testing.T) {
+	dup := errors.New("duplicate key")
+	qe := &batchExecuter{fail: map[string]error{"bad": dup}}
+
+	var b pgxdriver.Batch
+	var tags []string
+	for _, sql := range []string{"ok1", "ok2", "bad", "ok3", "ok4"} {
+		b.Queue(sql, 1).Exec(func(tag pgconn.CommandTag) error {
+			tags = append(tags, tag.String())
+			return nil
+		})
+	}
+
+	results, err := pgxdriver.ExecBatch(context.Background(), qe, &b, pgxdriver.BatchSize(3))
+	require.ErrorIs(t, err, dup)
+
+	assert.Equal(t, []int{3, 2}, qe.batches)
+	require.Len(t, results, 5)
+	assert.ErrorIs(t, results[0].Err, pgxdriver.ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, pgxdriver.ErrBatchAborted)
+	assert.Equal(t, dup, results[2].Err)
+	assert.NoError(t, results[3].Err)
+	assert.NoError(t, results[4].Err)
+	assert.Equal(t, "INSERT 0 1", results[4].CommandTag.String())
+	assert.Len(t, tags, 4)
+
+	failed := results.Failed()
+	require.Len(t, failed, 3)
+	assert.Equal(t, "bad", failed[2].SQL)
+}
+
+func TestExecBatch_CallbackError(t *testing.T) {
+	qe := &batchExecuter{}
+	cbErr := errors.New("callback failed")
+
+	var b pgxdriver.Batch
+	b.Queue("first").Exec(func(pgconn.CommandTag) error { return cbErr })
+	b.Queue("second")
+
+	results, err := pgxdriver.ExecBatch(context.Background(), qe, &b)
+	require.ErrorIs(t, err, cbErr)
+	// The statement was applied: only its callback failed.
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, cbErr, results[0].CallbackErr)
+	assert.NoError(t, results[1].Err)
+	assert.Empty(t, results.Failed())
+}
+
+func TestExecBatch_CallbackErrorInAbortedBatch(t *testing.T) {
+	dup := errors.New("duplicate key")
+	qe := &batchExecuter{fail: map[string]error{"bad": dup}}
+	cbErr := errors.New("callback failed")
+
+	var b pgxdriver.Batch
+	b.Queue("first").Exec(func(pgconn.CommandTag) error { return cbErr })
+	b.Queue("bad")
+
+	results, err := pgxdriver.ExecBatch(context.Background(), qe, &b)
+	require.ErrorIs(t, err, dup)
+	assert.ErrorIs(t, results[0].Err, pgxdriver.ErrBatchAborted)
+	assert.Equal(t, cbErr, results[0].CallbackErr)
+	assert.Equal(t, dup, results[1].Err)
+	assert.Len(t, results.Failed(), 2)
+}
+
+func TestExecBatch_InvalidSize(t *testing.T) {
+	_, err := pgxdriver.ExecBatch(context.Background(), &batchExecuter{}, &pgxdriver.Batch{}, pgxdriver.BatchSize(-1))
+	require.ErrorIs(t, err, pgxdriver.ErrInvalidBatchSize)
+}
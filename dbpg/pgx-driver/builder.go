@@ -0,0 +1,83 @@
+package pgxdriver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ExecBuilder builds the statement and executes it with any QueryExecuter
+// (e.g., *Postgres or *TxQueryExecuter), so the same code runs on the pool and in transactions.
+// Builders must use the Dollar placeholder format, as Postgres.Builder does.
+func ExecBuilder(ctx context.Context, qe QueryExecuter, b squirrel.Sqlizer) (pgconn.CommandTag, error) {
+	const op = "dbpg.pgx-driver.ExecBuilder"
+
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	tag, err := qe.Exec(ctx, sql, args...)
+	if err != nil {
+		return tag, fmt.Errorf("%s: exec: %w", op, err)
+	}
+
+	return tag, nil
+}
+
+// QueryBuilder builds the query and executes it with any QueryExecuter.
+// The caller must close the returned rows.
+func QueryBuilder(ctx context.Context, qe QueryExecuter, b squirrel.Sqlizer) (pgx.Rows, error) {
+	const op = "dbpg.pgx-driver.QueryBuilder"
+
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: build query: %w", op, err)
+	}
+
+	rows, err := qe.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: query: %w", op, err)
+	}
+
+	return rows, nil
+}
+
+// GetOne builds and executes the query and scans the first row into T (see ScanOne).
+// It returns an error wrapping ErrNotFound if the query returned no rows.
+func GetOne[T any](ctx context.Context, qe QueryExecuter, b squirrel.Sqlizer) (T, error) {
+	const op = "dbpg.pgx-driver.GetOne"
+
+	rows, err := QueryBuilder(ctx, qe, b)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	v, err := ScanOne[T](rows)
+	if err != nil {
+		return v, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return v, nil
+}
+
+// GetAll builds and executes the query and scans all rows into a slice of T (see ScanAll).
+func GetAll[T any](ctx context.Context, qe QueryExecuter, b squirrel.Sqlizer) ([]T, error) {
+	const op = "dbpg.pgx-driver.GetAll"
+
+	rows, err := QueryBuilder(ctx, qe, b)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ScanAll[T](rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
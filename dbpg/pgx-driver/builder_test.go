@@ -0,0 +1,50 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// recordingExecuter records Exec calls; other methods are not used by the tests.
+type recordingExecuter struct {
+	pgxdriver.QueryExecuter
+
+	sql  string
+	args []any
+}
+
+func (r *recordingExecuter) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.sql, r.args = sql, args
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (r *recordingExecuter) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	panic("unexpected Query call")
+}
+
+func TestExecBuilder(t *testing.T) {
+	qe := &recordingExecuter{}
+	builder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	tag, err := pgxdriver.ExecBuilder(context.Background(), qe,
+		builder.Update("users").Set("name", "alice").Where(squirrel.Eq{"id": 1}))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), tag.RowsAffected())
+	assert.Equal(t, "UPDATE users SET name = $1 WHERE id = $2", qe.sql)
+	assert.Equal(t, []any{"alice", 1}, qe.args)
+}
+
+func TestGetAll_BuildError(t *testing.T) {
+	// A SELECT without columns fails to build, so the executer is never called.
+	_, err := pgxdriver.GetAll[int](context.Background(), &recordingExecuter{}, squirrel.Select().From("users"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build query")
+}
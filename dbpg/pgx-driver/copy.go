@@ -19,16 +19,9 @@ var ErrInvalidTableName = errors.New("invalid table name type")
 func BulkInsert(ctx context.Context, qe QueryExecuter, tableName any, columns []string, data [][]any) (int64, error) {
 	const op = "pgxdriver.BulkInsert"
 
-	var ident pgx.Identifier
-	switch t := tableName.(type) {
-	case string:
-		ident = pgx.Identifier{t}
-	case []string:
-		ident = pgx.Identifier(t)
-	case pgx.Identifier:
-		ident = t
-	default:
-		return 0, fmt.Errorf("%w", ErrInvalidTableName)
+	ident, err := tableIdentifier(tableName)
+	if err != nil {
+		return 0, err
 	}
 
 	count, err := qe.CopyFrom(
@@ -43,3 +36,17 @@ func BulkInsert(ctx context.Context, qe QueryExecuter, tableName any, columns []
 
 	return count, nil
 }
+
+// tableIdentifier converts a table name given as a string, []string or pgx.Identifier.
+func tableIdentifier(tableName any) (pgx.Identifier, error) {
+	switch t := tableName.(type) {
+	case string:
+		return pgx.Identifier{t}, nil
+	case []string:
+		return pgx.Identifier(t), nil
+	case pgx.Identifier:
+		return t, nil
+	default:
+		return nil, fmt.Errorf("%w", ErrInvalidTableName)
+	}
+}
@@ -0,0 +1,241 @@
+package pgxdriver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidChunkSize is returned when ChunkSize < 0.
+var ErrInvalidChunkSize = errors.New("invalid chunk size: must be >= 0")
+
+// BulkInsertOption represents a functional configuration option for BulkInsertStream.
+type BulkInsertOption func(*bulkInsertConfig)
+
+// bulkInsertConfig holds BulkInsertStream settings.
+type bulkInsertConfig struct {
+	chunkSize  int
+	onProgress func(rowsCopied int64)
+}
+
+// ChunkSize splits the load into COPY operations of at most size rows each
+// (0, the default, copies all rows in one operation).
+func ChunkSize(size int) BulkInsertOption {
+	return func(c *bulkInsertConfig) {
+		c.chunkSize = size
+	}
+}
+
+// OnProgress sets a callback invoked after every completed COPY operation
+// with the total number of rows copied so far.
+func OnProgress(fn func(rowsCopied int64)) BulkInsertOption {
+	return func(c *bulkInsertConfig) {
+		c.onProgress = fn
+	}
+}
+
+// BulkInsertStream inserts rows produced by an iterator using the COPY FROM protocol,
+// without materialising the whole dataset in memory. The table name is a string, []string
+// or pgx.Identifier. An error yielded by the iterator aborts the current COPY operation.
+//
+// With ChunkSize, each chunk is a separate COPY operation: outside a transaction, chunks
+// copied before a failure stay committed. Pass a *TxQueryExecuter to load all-or-nothing.
+// Returns the number of rows inserted, including rows of completed chunks on failure.
+func BulkInsertStream(
+	ctx context.Context,
+	qe QueryExecuter,
+	tableName any,
+	columns []string,
+	rows iter.Seq2[[]any, error],
+	opts ...BulkInsertOption,
+) (int64, error) {
+	const op = "dbpg.pgx-driver.BulkInsertStream"
+
+	var cfg bulkInsertConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.chunkSize < 0 {
+		return 0, fmt.Errorf("%s: validation: %w", op, ErrInvalidChunkSize)
+	}
+
+	ident, err := tableIdentifier(tableName)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	next, stop := iter.Pull2(rows)
+	defer stop()
+
+	src := &chunkSource{next: next, limit: cfg.chunkSize}
+	var total int64
+	for src.more() {
+		src.count = 0
+
+		n, err := qe.CopyFrom(ctx, ident, columns, src)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("%s: copy from: %w", op, err)
+		}
+
+		if cfg.onProgress != nil {
+			cfg.onProgress(total)
+		}
+	}
+	if src.err != nil {
+		return total, fmt.Errorf("%s: read rows: %w", op, src.err)
+	}
+
+	return total, nil
+}
+
+// ChanRows adapts a channel of rows to an iterator for BulkInsertStream.
+// The iterator ends when the channel is closed.
+func ChanRows(ch <-chan []any) iter.Seq2[[]any, error] {
+	return func(yield func([]any, error) bool) {
+		for row := range ch {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// chunkSource implements pgx.CopyFromSource over a pulled iterator,
+// ending each COPY operation after limit rows.
+type chunkSource struct {
+	next  func() ([]any, error, bool)
+	limit int
+	count int
+
+	row     []any
+	pending []any
+	peeked  bool
+	done    bool
+	err     error
+}
+
+// more reports whether the iterator has another row, fetching it ahead if needed.
+func (s *chunkSource) more() bool {
+	if s.peeked {
+		return true
+	}
+	if s.done || s.err != nil {
+		return false
+	}
+
+	row, err, ok := s.next()
+	switch {
+	case !ok:
+		s.done = true
+		return false
+	case err != nil:
+		s.err = err
+		return false
+	}
+
+	s.pending, s.peeked = row, true
+	return true
+}
+
+// Next implements pgx.CopyFromSource.
+func (s *chunkSource) Next() bool {
+	if s.limit > 0 && s.count >= s.limit {
+		return false
+	}
+	if !s.more() {
+		return false
+	}
+
+	s.row, s.pending, s.peeked = s.pending, nil, false
+	s.count++
+	return true
+}
+
+// Values implements pgx.CopyFromSource.
+func (s *chunkSource) Values() ([]any, error) {
+	return s.row, nil
+}
+
+// Err implements pgx.CopyFromSource.
+func (s *chunkSource) Err() error {
+	return s.err
+}
+
+// CopyTo runs the query with any QueryExecuter and streams the result to w as CSV
+// with a header row of column names. Values are written in PostgreSQL text format and
+// quoted as COPY ... TO STDOUT (FORMAT csv) does: NULL is written as an empty field and
+// an empty string as "", so the output can be loaded back with COPY ... FROM (FORMAT csv).
+// Returns the number of data rows written.
+func CopyTo(ctx context.Context, qe QueryExecuter, w io.Writer, sql string, args ...any) (int64, error) {
+	const op = "dbpg.pgx-driver.CopyTo"
+
+	// Request text results so that raw values are PostgreSQL's text representation.
+	rows, err := qe.Query(ctx, sql, append([]any{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: query: %w", op, err)
+	}
+	defer rows.Close()
+
+	bw := bufio.NewWriter(w)
+	var line []byte
+
+	fields := rows.FieldDescriptions()
+	for i, f := range fields {
+		line = appendCSVField(line, i, []byte(f.Name))
+	}
+	if _, err := bw.Write(append(line, '\n')); err != nil {
+		return 0, fmt.Errorf("%s: write header: %w", op, err)
+	}
+
+	var count int64
+	for rows.Next() {
+		line = line[:0]
+		for i, raw := range rows.RawValues() {
+			line = appendCSVField(line, i, raw)
+		}
+		if _, err := bw.Write(append(line, '\n')); err != nil {
+			return count, fmt.Errorf("%s: write row %d: %w", op, count, err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("%s: read rows: %w", op, err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return count, fmt.Errorf("%s: flush: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// appendCSVField appends the i-th field of a CSV line. A nil value (NULL) is written
+// as an empty field; a value that is empty, equals \. or contains a comma, quote or
+// line break is quoted with inner quotes doubled, as COPY does.
+func appendCSVField(line []byte, i int, value []byte) []byte {
+	if i > 0 {
+		line = append(line, ',')
+	}
+	if value == nil {
+		return line
+	}
+	if len(value) > 0 && string(value) != `\.` && !bytes.ContainsAny(value, ",\"\r\n") {
+		return append(line, value...)
+	}
+
+	line = append(line, '"')
+	for _, b := range value {
+		if b == '"' {
+			line = append(line, '"')
+		}
+		line = append(line, b)
+	}
+	return append(line, '"')
+}
@@ -0,0 +1,144 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// copyRecorder drains every CopyFrom source and records the rows of each call.
+type copyRecorder struct {
+	pgxdriver.QueryExecuter
+
+	chunks [][][]any
+}
+
+func (c *copyRecorder) CopyFrom(
+	_ context.Context,
+	_ pgx.Identifier,
+	_ []string,
+	src pgx.CopyFromSource,
+) (int64, error) {
+	var chunk [][]any
+	for src.Next() {
+		row, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		chunk = append(chunk, row)
+	}
+	if err := src.Err(); err != nil {
+		return 0, err
+	}
+	c.chunks = append(c.chunks, chunk)
+	return int64(len(chunk)), nil
+}
+
+func numbers(n int, failAt int) iter.Seq2[[]any, error] {
+	return func(yield func([]any, error) bool) {
+		for i := range n {
+			if i == failAt {
+				yield(nil, errors.New("source failed"))
+				return
+			}
+			if !yield([]any{i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestBulkInsertStream_Chunks(t *testing.T) {
+	qe := &copyRecorder{}
+	var progress []int64
+
+	n, err := pgxdriver.BulkInsertStream(context.Background(), qe, "numbers", []string{"n"}, numbers(6, -1),
+		pgxdriver.ChunkSize(3),
+		pgxdriver.OnProgress(func(rows int64) { progress = append(progress, rows) }),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(6), n)
+	assert.Len(t, qe.chunks, 2, "no empty trailing COPY")
+	assert.Equal(t, []int64{3, 6}, progress)
+}
+
+func TestBulkInsertStream_SourceError(t *testing.T) {
+	qe := &copyRecorder{}
+
+	n, err := pgxdriver.BulkInsertStream(context.Background(), qe, "numbers", []string{"n"}, numbers(10, 4),
+		pgxdriver.ChunkSize(2))
+	require.ErrorContains(t, err, "source failed")
+	assert.Equal(t, int64(4), n)
+}
+
+func TestBulkInsertStream_Channel(t *testing.T) {
+	qe := &copyRecorder{}
+	ch := make(chan []any, 3)
+	ch <- []any{1}
+	ch <- []any{2}
+	close(ch)
+
+	n, err := pgxdriver.BulkInsertStream(context.Background(), qe, "numbers", []string{"n"}, pgxdriver.ChanRows(ch))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Len(t, qe.chunks, 1)
+}
+
+// rawRows is a pgx.Rows returning fixed raw text values.
+type rawRows struct {
+	pgx.Rows
+
+	fields []pgconn.FieldDescription
+	values [][][]byte
+	row    int
+}
+
+func (r *rawRows) Close()                                       {}
+func (r *rawRows) Err() error                                   { return nil }
+func (r *rawRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+func (r *rawRows) RawValues() [][]byte                          { return r.values[r.row-1] }
+
+func (r *rawRows) Next() bool {
+	r.row++
+	return r.row <= len(r.values)
+}
+
+// rowsExecuter returns rows from Query.
+type rowsExecuter struct {
+	pgxdriver.QueryExecuter
+
+	rows pgx.Rows
+}
+
+func (e *rowsExecuter) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return e.rows, nil
+}
+
+func TestCopyTo_QuotesLikeCopy(t *testing.T) {
+	qe := &rowsExecuter{rows: &rawRows{
+		fields: []pgconn.FieldDescription{{Name: "id"}, {Name: "name"}, {Name: "note"}},
+		values: [][][]byte{
+			{[]byte("1"), []byte(""), nil},
+			{[]byte("2"), []byte(`say "hi", bye`), []byte("two\nlines")},
+			{[]byte("3"), []byte(`\.`), []byte(" padded ")},
+		},
+	}}
+
+	var out strings.Builder
+	count, err := pgxdriver.CopyTo(context.Background(), qe, &out, "SELECT id, name, note FROM t")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.Equal(t, "id,name,note\n"+
+		"1,\"\",\n"+
+		"2,\"say \"\"hi\"\", bye\",\"two\nlines\"\n"+
+		"3,\"\\.\", padded \n", out.String())
+}
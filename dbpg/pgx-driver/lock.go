@@ -0,0 +1,289 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	_defaultLockCheckInterval   = time.Second
+	_defaultElectionRetryPeriod = 5 * time.Second
+
+	_lockReleaseTimeout = 5 * time.Second
+)
+
+var (
+	// ErrInvalidLockHealthCheck is returned when LockHealthCheckInterval < 0.
+	ErrInvalidLockHealthCheck = errors.New("invalid lock health check interval: must be >= 0")
+	// ErrLockNotAcquired is returned by TryWithLock when the lock is held by another session.
+	ErrLockNotAcquired = errors.New("advisory lock not acquired")
+	// ErrLockLost is the cause of the lock context cancellation when the lock connection is lost.
+	ErrLockLost = errors.New("advisory lock lost")
+)
+
+// LockHealthCheckInterval sets how often the connection holding an advisory lock is pinged
+// (1 second by default). When a ping fails, the lock is considered lost.
+func LockHealthCheckInterval(interval time.Duration) Option {
+	return func(p *Postgres) {
+		p.lockCheckInterval = interval
+	}
+}
+
+// AdvisoryLockKey derives an advisory lock key from a name, e.g. a job name.
+func AdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64()) //nolint:gosec // Any 64-bit value is a valid lock key.
+}
+
+// WithLock waits for the session-level advisory lock key (pg_advisory_lock), runs fn and
+// releases the lock. The lock is held by a dedicated pooled connection, so fn may use the pool
+// or transactions freely. The context passed to fn is canceled when ctx is canceled or when the
+// lock connection is lost, with ErrLockLost as its cause; then the lock is no longer exclusive
+// and fn should stop. WithLock returns an error wrapping ErrLockLost in that case.
+func (p *Postgres) WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) error {
+	const op = "dbpg.pgx-driver.WithLock"
+
+	conn, err := p.acquireLock(ctx, key, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.holdLock(ctx, conn, key, fn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// TryWithLock works like WithLock but does not wait: if the lock is held by another session,
+// it returns an error wrapping ErrLockNotAcquired without running fn (pg_try_advisory_lock).
+func (p *Postgres) TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) error {
+	const op = "dbpg.pgx-driver.TryWithLock"
+
+	conn, err := p.acquireLock(ctx, key, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.holdLock(ctx, conn, key, fn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// acquireLock acquires the advisory lock on a dedicated connection, waiting for it if wait is set.
+func (p *Postgres) acquireLock(ctx context.Context, key int64, wait bool) (*pgxpool.Conn, error) {
+	conn, err := p.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+
+	acquired := true
+	if wait {
+		_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", key)
+	} else {
+		err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+	}
+	if err != nil {
+		// The lock may have been granted before the call was canceled: close the session to be sure.
+		destroyConn(conn)
+		return nil, fmt.Errorf("lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, ErrLockNotAcquired
+	}
+
+	return conn, nil
+}
+
+// holdLock runs fn while pinging the lock connection, then releases the lock.
+func (p *Postgres) holdLock(ctx context.Context, conn *pgxpool.Conn, key int64, fn func(ctx context.Context) error) error {
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	interval := p.lockCheckInterval
+	if interval == 0 {
+		interval = _defaultLockCheckInterval
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				pingCtx, cancelPing := context.WithTimeout(context.Background(), interval)
+				err := conn.Ping(pingCtx)
+				cancelPing()
+				if err != nil {
+					cancel(fmt.Errorf("%w: %w", ErrLockLost, err))
+					return
+				}
+			}
+		}
+	}()
+
+	err := fn(lockCtx)
+	close(done)
+	wg.Wait()
+
+	lost := context.Cause(lockCtx)
+	if !errors.Is(lost, ErrLockLost) {
+		lost = nil
+	}
+	releaseLock(conn, key, lost != nil)
+
+	return errors.Join(err, lost)
+}
+
+// releaseLock unlocks the advisory lock and returns the connection to the pool.
+// If the connection is broken or unlocking fails, the connection is closed,
+// which releases the lock on the server.
+func releaseLock(conn *pgxpool.Conn, key int64, broken bool) {
+	if !broken {
+		ctx, cancel := context.WithTimeout(context.Background(), _lockReleaseTimeout)
+		defer cancel()
+
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", key); err == nil {
+			conn.Release()
+			return
+		}
+	}
+
+	destroyConn(conn)
+}
+
+// destroyConn closes the connection and removes it from the pool.
+func destroyConn(conn *pgxpool.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), _lockReleaseTimeout)
+	defer cancel()
+
+	_ = conn.Conn().Close(ctx)
+	conn.Release()
+}
+
+// ElectorOption represents a functional configuration option for a LeaderElector.
+type ElectorOption func(*LeaderElector)
+
+// OnElected sets the function called when leadership is acquired. The context is canceled when
+// leadership is lost or the elector stops; the function may block until then.
+func OnElected(fn func(ctx context.Context)) ElectorOption {
+	return func(e *LeaderElector) {
+		e.onElected = fn
+	}
+}
+
+// OnRevoked sets the function called after leadership is lost or released.
+func OnRevoked(fn func()) ElectorOption {
+	return func(e *LeaderElector) {
+		e.onRevoked = fn
+	}
+}
+
+// ElectionRetryPeriod sets how often a follower tries to acquire leadership (5 seconds by default).
+func ElectionRetryPeriod(period time.Duration) ElectorOption {
+	return func(e *LeaderElector) {
+		e.retryPeriod = period
+	}
+}
+
+// LeaderElector elects a single leader among processes sharing a lock key, e.g. the replicas
+// of a service running scheduled jobs. The leader holds a session-level advisory lock on a
+// dedicated connection; leadership passes to another process when the leader stops, its
+// connection is lost or the database restarts.
+type LeaderElector struct {
+	p   *Postgres
+	key int64
+
+	onElected   func(ctx context.Context)
+	onRevoked   func()
+	retryPeriod time.Duration
+
+	leader atomic.Bool
+}
+
+// NewLeaderElector creates a LeaderElector for the advisory lock key. Call Run to take part in elections.
+func (p *Postgres) NewLeaderElector(key int64, opts ...ElectorOption) *LeaderElector {
+	e := &LeaderElector{
+		p:           p,
+		key:         key,
+		retryPeriod: _defaultElectionRetryPeriod,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.retryPeriod <= 0 {
+		e.retryPeriod = _defaultElectionRetryPeriod
+	}
+
+	return e
+}
+
+// IsLeader reports whether this process currently holds leadership.
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run tries to acquire leadership every ElectionRetryPeriod and holds it until it is lost,
+// then tries again. It blocks until ctx is canceled, releasing leadership, and returns nil.
+func (e *LeaderElector) Run(ctx context.Context) error {
+	for {
+		conn, err := e.p.acquireLock(ctx, e.key, false)
+		switch {
+		case err == nil:
+			e.lead(ctx, conn)
+		case errors.Is(err, ErrLockNotAcquired) || ctx.Err() != nil:
+			// Another process is the leader, or the elector is stopping.
+		default:
+			e.p.logger.Warn("leader election attempt failed", "key", e.key, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.retryPeriod):
+		}
+	}
+}
+
+// lead holds leadership until it is lost or ctx is canceled.
+func (e *LeaderElector) lead(ctx context.Context, conn *pgxpool.Conn) {
+	e.leader.Store(true)
+	e.p.logger.Info("leadership acquired", "key", e.key)
+
+	err := e.p.holdLock(ctx, conn, e.key, func(ctx context.Context) error {
+		if e.onElected != nil {
+			e.onElected(ctx)
+		}
+		<-ctx.Done()
+		return nil
+	})
+
+	e.leader.Store(false)
+	if err != nil {
+		e.p.logger.Warn("leadership lost", "key", e.key, "error", err)
+	} else {
+		e.p.logger.Info("leadership released", "key", e.key)
+	}
+
+	if e.onRevoked != nil {
+		e.onRevoked()
+	}
+}
@@ -0,0 +1,57 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	assert.Equal(t, pgxdriver.AdvisoryLockKey("cleanup"), pgxdriver.AdvisoryLockKey("cleanup"))
+	assert.NotEqual(t, pgxdriver.AdvisoryLockKey("cleanup"), pgxdriver.AdvisoryLockKey("report"))
+}
+
+func TestNew_InvalidLockHealthCheck(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	_, err = pgxdriver.New(unreachableDSN, log, pgxdriver.LockHealthCheckInterval(-time.Second))
+	require.ErrorIs(t, err, pgxdriver.ErrInvalidLockHealthCheck)
+}
+
+func TestLocks_Unreachable(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	pg, err := pgxdriver.New(unreachableDSN, log)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	called := false
+	err = pg.TryWithLock(ctx, 1, func(context.Context) error {
+		called = true
+		return nil
+	})
+	require.Error(t, err)
+	assert.False(t, called)
+
+	elected := false
+	elector := pg.NewLeaderElector(1,
+		pgxdriver.OnElected(func(context.Context) { elected = true }),
+		pgxdriver.ElectionRetryPeriod(10*time.Millisecond),
+	)
+	runCtx, stop := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer stop()
+
+	require.NoError(t, elector.Run(runCtx))
+	assert.False(t, elected)
+	assert.False(t, elector.IsLeader())
+}
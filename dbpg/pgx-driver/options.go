@@ -55,6 +55,14 @@ func MaxRetryDelay(delay time.Duration) Option {
 	}
 }
 
+// QueryTracer attaches a Tracer to every pool connection to record metrics
+// and log slow queries, including calls made inside transactions.
+func QueryTracer(tracer *Tracer) Option {
+	return func(p *Postgres) {
+		p.tracer = tracer
+	}
+}
+
 // validate checks that all Postgres client configuration parameters are valid.
 // It returns an error if any parameter violates its constraints.
 func (p *Postgres) validate() error {
@@ -77,5 +85,16 @@ func (p *Postgres) validate() error {
 	if p.baseRetryDelay > p.maxRetryDelay {
 		return ErrBaseExceedsMaxDelay
 	}
+	if err := p.settings.validate(p.maxPoolSize); err != nil {
+		return err
+	}
+
+	if p.replicaCheckInterval < 0 || p.replicaCheckTimeout < 0 {
+		return ErrInvalidReplicaHealthCheck
+	}
+
+	if p.lockCheckInterval < 0 {
+		return ErrInvalidLockHealthCheck
+	}
 	return nil
 }
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+var (
+	// ErrNilPool is returned when NewRelay is called without a Postgres client.
+	ErrNilPool = errors.New("postgres client must not be nil")
+	// ErrNilPublisher is returned when NewRelay is called without a publisher.
+	ErrNilPublisher = errors.New("publisher must not be nil")
+	// ErrInvalidTable is returned when the table is not a valid, optionally schema-qualified, identifier.
+	ErrInvalidTable = errors.New("invalid table: must be an identifier or schema.identifier")
+	// ErrEmptyTopic is returned when Enqueue is called with an empty topic.
+	ErrEmptyTopic = errors.New("topic must not be empty")
+	// ErrInvalidBatchSize is returned when BatchSize <= 0.
+	ErrInvalidBatchSize = errors.New("invalid batch size: must be > 0")
+	// ErrInvalidPollInterval is returned when PollInterval <= 0.
+	ErrInvalidPollInterval = errors.New("invalid poll interval: must be > 0")
+	// ErrInvalidMaxAttempts is returned when MaxAttempts < 0.
+	ErrInvalidMaxAttempts = errors.New("invalid max attempts: must be >= 0")
+	// ErrInvalidRetryDelay is returned when BaseRetryDelay or MaxRetryDelay <= 0,
+	// or BaseRetryDelay > MaxRetryDelay.
+	ErrInvalidRetryDelay = errors.New("invalid retry delay: must be > 0 and base <= max")
+	// ErrInvalidRetention is returned when Retention or CleanupInterval < 0.
+	ErrInvalidRetention = errors.New("invalid retention: must be >= 0")
+)
+
+// identifierPattern matches an identifier optionally qualified with a schema.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Option represents a functional configuration option for the Relay.
+type Option func(*Relay)
+
+// Table sets the outbox table (DefaultTable by default). The name may be qualified with a schema.
+func Table(name string) Option {
+	return func(r *Relay) {
+		r.table = name
+	}
+}
+
+// BatchSize sets the maximum number of messages locked and published per poll (100 by default).
+func BatchSize(size int) Option {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// PollInterval sets the delay between polls when the previous poll did not fill a batch
+// (1 second by default).
+func PollInterval(interval time.Duration) Option {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+// MaxAttempts sets the number of publish attempts after which a message is no longer retried
+// and stays in the table for inspection (0, the default, retries forever).
+func MaxAttempts(attempts int) Option {
+	return func(r *Relay) {
+		r.maxAttempts = attempts
+	}
+}
+
+// BaseRetryDelay sets the delay before the first retry of a failed message (1 second by default).
+// The delay doubles with every failed attempt up to MaxRetryDelay.
+func BaseRetryDelay(delay time.Duration) Option {
+	return func(r *Relay) {
+		r.baseRetryDelay = delay
+	}
+}
+
+// MaxRetryDelay sets the upper bound of the retry delay (5 minutes by default).
+func MaxRetryDelay(delay time.Duration) Option {
+	return func(r *Relay) {
+		r.maxRetryDelay = delay
+	}
+}
+
+// Retention sets how long sent messages are kept before cleanup (7 days by default).
+// Zero disables cleanup.
+func Retention(retention time.Duration) Option {
+	return func(r *Relay) {
+		r.retention = retention
+	}
+}
+
+// CleanupInterval sets how often sent messages older than Retention are deleted (1 hour by default).
+func CleanupInterval(interval time.Duration) Option {
+	return func(r *Relay) {
+		r.cleanupInterval = interval
+	}
+}
+
+// validate checks that all Relay configuration parameters are valid.
+func (r *Relay) validate() error {
+	if r.pg == nil {
+		return ErrNilPool
+	}
+
+	if r.publisher == nil {
+		return ErrNilPublisher
+	}
+
+	if !identifierPattern.MatchString(r.table) {
+		return ErrInvalidTable
+	}
+
+	if r.batchSize <= 0 {
+		return ErrInvalidBatchSize
+	}
+
+	if r.pollInterval <= 0 {
+		return ErrInvalidPollInterval
+	}
+
+	if r.maxAttempts < 0 {
+		return ErrInvalidMaxAttempts
+	}
+
+	if r.baseRetryDelay <= 0 || r.maxRetryDelay <= 0 || r.baseRetryDelay > r.maxRetryDelay {
+		return ErrInvalidRetryDelay
+	}
+
+	if r.retention < 0 || r.cleanupInterval < 0 {
+		return ErrInvalidRetention
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package outbox implements the transactional outbox pattern on PostgreSQL.
+//
+// Messages are written to an outbox table with Enqueue in the same transaction as the
+// business data, so they are stored if and only if the transaction commits. A Relay polls
+// the table with FOR UPDATE SKIP LOCKED, publishes pending messages to Kafka or RabbitMQ,
+// marks them sent, retries failed messages with exponential backoff and deletes old sent
+// messages. Delivery is at-least-once: consumers should deduplicate by the message ID,
+// which is sent in the HeaderID header.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+const (
+	// DefaultTable is the outbox table used by Enqueue and by a Relay without the Table option.
+	DefaultTable = "outbox"
+
+	// HeaderID is the header carrying the outbox message ID to consumers.
+	HeaderID = "outbox-id"
+)
+
+// Message is a message stored in the outbox table.
+type Message struct {
+	ID        int64
+	Topic     string
+	Key       []byte
+	Payload   []byte
+	Headers   map[string]string
+	Attempts  int // Number of failed publish attempts.
+	CreatedAt time.Time
+}
+
+// Enqueue stores a message in the DefaultTable outbox table. Pass the executer of the transaction
+// writing the business data (e.g. the one returned by transaction.Executor within
+// transaction.Manager.RunInTransaction) to publish the message only if the transaction commits.
+func Enqueue(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	topic string,
+	key, payload []byte,
+	headers map[string]string,
+) (int64, error) {
+	return EnqueueTo(ctx, tx, DefaultTable, topic, key, payload, headers)
+}
+
+// EnqueueTo works like Enqueue with the given outbox table. Returns the message ID.
+func EnqueueTo(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	table, topic string,
+	key, payload []byte,
+	headers map[string]string,
+) (int64, error) {
+	const op = "dbpg.pgx-driver.outbox.Enqueue"
+
+	ident, err := tableIdentifier(table)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if topic == "" {
+		return 0, fmt.Errorf("%s: %w", op, ErrEmptyTopic)
+	}
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx,
+		"INSERT INTO "+ident.Sanitize()+" (topic, key, payload, headers) VALUES ($1, $2, $3, $4) RETURNING id",
+		topic, key, payload, headers,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: insert: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SchemaSQL returns the statements creating the outbox table and its indexes,
+// e.g. for a migration file.
+func SchemaSQL(table string) (string, error) {
+	ident, err := tableIdentifier(table)
+	if err != nil {
+		return "", err
+	}
+
+	name := ident[len(ident)-1]
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id              BIGSERIAL PRIMARY KEY,
+	topic           TEXT        NOT NULL,
+	key             BYTEA,
+	payload         BYTEA       NOT NULL,
+	headers         JSONB       NOT NULL DEFAULT '{}',
+	attempts        INT         NOT NULL DEFAULT 0,
+	last_error      TEXT,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	sent_at         TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (next_attempt_at, id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS %[3]s ON %[1]s (sent_at) WHERE sent_at IS NOT NULL;
+`,
+		ident.Sanitize(),
+		pgx.Identifier{name + "_pending_idx"}.Sanitize(),
+		pgx.Identifier{name + "_sent_idx"}.Sanitize(),
+	), nil
+}
+
+// CreateTable creates the outbox table and its indexes if they do not exist.
+func CreateTable(ctx context.Context, qe pgxdriver.QueryExecuter, table string) error {
+	const op = "dbpg.pgx-driver.outbox.CreateTable"
+
+	schema, err := SchemaSQL(table)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := qe.Exec(ctx, schema); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// tableIdentifier parses a table name optionally qualified with a schema.
+func tableIdentifier(table string) (pgx.Identifier, error) {
+	if !identifierPattern.MatchString(table) {
+		return nil, ErrInvalidTable
+	}
+	return pgx.Identifier(strings.Split(table, ".")), nil
+}
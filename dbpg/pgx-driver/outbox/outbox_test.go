@@ -0,0 +1,142 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/outbox"
+	kafkav2 "github.com/wb-go/wbf/kafka/kafka-v2"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+var (
+	_ outbox.KafkaSender  = (*kafkav2.Producer)(nil)
+	_ outbox.RabbitSender = (*rabbitmq.Publisher)(nil)
+)
+
+// insertRecorder records QueryRow calls and returns id 42.
+type insertRecorder struct {
+	pgxdriver.QueryExecuter
+
+	sql  string
+	args []any
+}
+
+func (r *insertRecorder) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	r.sql, r.args = sql, args
+	return idRow(42)
+}
+
+type idRow int64
+
+func (r idRow) Scan(dest ...any) error {
+	*dest[0].(*int64) = int64(r)
+	return nil
+}
+
+func TestEnqueue(t *testing.T) {
+	tx := &insertRecorder{}
+
+	id, err := outbox.EnqueueTo(context.Background(), tx, "events.outbox", "orders",
+		[]byte("k"), []byte(`{"id":1}`), map[string]string{"type": "created"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), id)
+	assert.Equal(t,
+		`INSERT INTO "events"."outbox" (topic, key, payload, headers) VALUES ($1, $2, $3, $4) RETURNING id`, tx.sql)
+	assert.Equal(t, []any{"orders", []byte("k"), []byte(`{"id":1}`), map[string]string{"type": "created"}}, tx.args)
+
+	_, err = outbox.Enqueue(context.Background(), tx, "", nil, nil, nil)
+	require.ErrorIs(t, err, outbox.ErrEmptyTopic)
+
+	_, err = outbox.EnqueueTo(context.Background(), tx, "bad table", "orders", nil, nil, nil)
+	require.ErrorIs(t, err, outbox.ErrInvalidTable)
+}
+
+func TestSchemaSQL(t *testing.T) {
+	schema, err := outbox.SchemaSQL("events.outbox")
+	require.NoError(t, err)
+
+	assert.Contains(t, schema, `CREATE TABLE IF NOT EXISTS "events"."outbox"`)
+	assert.Contains(t, schema, `CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "events"."outbox"`)
+}
+
+func TestNewRelay_Validation(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+	pub := outbox.PublisherFunc(func(context.Context, outbox.Message) error { return nil })
+
+	_, err = outbox.NewRelay(nil, pub, log)
+	require.ErrorIs(t, err, outbox.ErrNilPool)
+
+	pg := &pgxdriver.Postgres{}
+	_, err = outbox.NewRelay(pg, nil, log)
+	require.ErrorIs(t, err, outbox.ErrNilPublisher)
+
+	_, err = outbox.NewRelay(pg, pub, log, outbox.BatchSize(0))
+	require.ErrorIs(t, err, outbox.ErrInvalidBatchSize)
+
+	_, err = outbox.NewRelay(pg, pub, log, outbox.BaseRetryDelay(time.Hour), outbox.MaxRetryDelay(time.Minute))
+	require.ErrorIs(t, err, outbox.ErrInvalidRetryDelay)
+
+	_, err = outbox.NewRelay(pg, pub, log, outbox.Table("outbox; DROP TABLE users"))
+	require.ErrorIs(t, err, outbox.ErrInvalidTable)
+
+	_, err = outbox.NewRelay(pg, pub, log, outbox.Table("events.outbox"), outbox.MaxAttempts(10))
+	require.NoError(t, err)
+}
+
+type kafkaRecorder struct {
+	key, value []byte
+	headers    []kafka.Header
+}
+
+func (k *kafkaRecorder) Send(_ context.Context, key, value []byte, headers ...kafka.Header) error {
+	k.key, k.value, k.headers = key, value, headers
+	return nil
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	producer := &kafkaRecorder{}
+	pub := outbox.KafkaPublisher(map[string]outbox.KafkaSender{"orders": producer})
+
+	msg := outbox.Message{ID: 7, Topic: "orders", Key: []byte("k"), Payload: []byte("v"),
+		Headers: map[string]string{"b": "2", "a": "1"}}
+	require.NoError(t, pub.Publish(context.Background(), msg))
+
+	assert.Equal(t, []byte("k"), producer.key)
+	assert.Equal(t, []kafka.Header{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+		{Key: outbox.HeaderID, Value: []byte("7")},
+	}, producer.headers)
+
+	msg.Topic = "payments"
+	require.ErrorIs(t, pub.Publish(context.Background(), msg), outbox.ErrUnknownTopic)
+}
+
+type rabbitRecorder struct {
+	routingKey string
+	opts       []rabbitmq.PublishOption
+}
+
+func (r *rabbitRecorder) Publish(_ context.Context, _ []byte, routingKey string, opts ...rabbitmq.PublishOption) error {
+	r.routingKey, r.opts = routingKey, opts
+	return nil
+}
+
+func TestRabbitPublisher(t *testing.T) {
+	publisher := &rabbitRecorder{}
+	pub := outbox.RabbitPublisher(publisher)
+
+	require.NoError(t, pub.Publish(context.Background(), outbox.Message{ID: 7, Topic: "orders.created"}))
+	assert.Equal(t, "orders.created", publisher.routingKey)
+	assert.Len(t, publisher.opts, 1)
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+// ErrUnknownTopic is returned by KafkaPublisher for a topic without a producer.
+var ErrUnknownTopic = errors.New("no producer for topic")
+
+// Publisher delivers outbox messages to a broker.
+type Publisher interface {
+	// Publish delivers a message. An error makes the Relay retry the message later.
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface.
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+// Publish calls f(ctx, msg).
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// KafkaSender is implemented by kafkav2.Producer.
+type KafkaSender interface {
+	Send(ctx context.Context, key, value []byte, headers ...kafka.Header) error
+}
+
+// KafkaPublisher returns a Publisher sending messages with the producer of their topic,
+// e.g. a kafkav2.Producer created for that topic. Message headers and HeaderID are sent
+// as Kafka headers.
+func KafkaPublisher(producers map[string]KafkaSender) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		producer, ok := producers[msg.Topic]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownTopic, msg.Topic)
+		}
+
+		headers := make([]kafka.Header, 0, len(msg.Headers)+1)
+		for _, k := range slices.Sorted(maps.Keys(msg.Headers)) {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(msg.Headers[k])})
+		}
+		headers = append(headers, kafka.Header{Key: HeaderID, Value: []byte(strconv.FormatInt(msg.ID, 10))})
+
+		return producer.Send(ctx, msg.Key, msg.Payload, headers...)
+	})
+}
+
+// RabbitSender is implemented by rabbitmq.Publisher.
+type RabbitSender interface {
+	Publish(ctx context.Context, body []byte, routingKey string, opts ...rabbitmq.PublishOption) error
+}
+
+// RabbitPublisher returns a Publisher publishing messages to the publisher's exchange with
+// the topic as routing key. Message headers and HeaderID are sent as AMQP headers; AMQP has
+// no message key, so a non-empty key is sent in the "key" header.
+func RabbitPublisher(publisher RabbitSender) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		headers := make(amqp091.Table, len(msg.Headers)+2)
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		if len(msg.Key) > 0 {
+			headers["key"] = string(msg.Key)
+		}
+		headers[HeaderID] = msg.ID
+
+		return publisher.Publish(ctx, msg.Payload, msg.Topic, rabbitmq.WithHeaders(headers))
+	})
+}
@@ -0,0 +1,234 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_defaultBatchSize       = 100
+	_defaultPollInterval    = time.Second
+	_defaultBaseRetryDelay  = time.Second
+	_defaultMaxRetryDelay   = 5 * time.Minute
+	_defaultRetention       = 7 * 24 * time.Hour
+	_defaultCleanupInterval = time.Hour
+
+	_cleanupBatchSize = 10000
+)
+
+// Relay publishes pending outbox messages. Several relays, e.g. one per service replica,
+// may run against the same table: each message is locked by one of them at a time.
+type Relay struct {
+	pg        *pgxdriver.Postgres
+	publisher Publisher
+	logger    logger.Logger
+
+	table           string
+	ident           pgx.Identifier
+	batchSize       int
+	pollInterval    time.Duration
+	maxAttempts     int
+	baseRetryDelay  time.Duration
+	maxRetryDelay   time.Duration
+	retention       time.Duration
+	cleanupInterval time.Duration
+}
+
+// NewRelay creates a Relay reading the outbox table through pg and publishing with publisher,
+// e.g. KafkaPublisher or RabbitPublisher.
+func NewRelay(pg *pgxdriver.Postgres, publisher Publisher, logger logger.Logger, opts ...Option) (*Relay, error) {
+	const op = "dbpg.pgx-driver.outbox.NewRelay"
+
+	r := &Relay{
+		pg:        pg,
+		publisher: publisher,
+		logger:    logger,
+
+		table:           DefaultTable,
+		batchSize:       _defaultBatchSize,
+		pollInterval:    _defaultPollInterval,
+		baseRetryDelay:  _defaultBaseRetryDelay,
+		maxRetryDelay:   _defaultMaxRetryDelay,
+		retention:       _defaultRetention,
+		cleanupInterval: _defaultCleanupInterval,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("%s: validation: %w", op, err)
+	}
+
+	ident, err := tableIdentifier(r.table)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	r.ident = ident
+
+	return r, nil
+}
+
+// Run polls and publishes messages and periodically deletes old sent messages until ctx is
+// canceled. When a poll fills a whole batch, the next poll starts immediately.
+// Errors are logged and do not stop the relay. Returns nil after ctx is canceled.
+func (r *Relay) Run(ctx context.Context) error {
+	const op = "dbpg.pgx-driver.outbox.Run"
+
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	var cleanup <-chan time.Time
+	if r.retention > 0 && r.cleanupInterval > 0 {
+		ticker := time.NewTicker(r.cleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-poll.C:
+			n, err := r.ProcessBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.LogAttrs(ctx, logger.ErrorLevel, "outbox poll failed",
+					logger.String("op", op),
+					logger.String("table", r.table),
+					logger.Any("error", err),
+				)
+			}
+
+			next := r.pollInterval
+			if err == nil && n == r.batchSize {
+				next = 0
+			}
+			poll.Reset(next)
+		case <-cleanup:
+			if _, err := r.Cleanup(ctx); err != nil && ctx.Err() == nil {
+				r.logger.LogAttrs(ctx, logger.ErrorLevel, "outbox cleanup failed",
+					logger.String("op", op),
+					logger.String("table", r.table),
+					logger.Any("error", err),
+				)
+			}
+		}
+	}
+}
+
+// ProcessBatch locks up to BatchSize due messages with FOR UPDATE SKIP LOCKED, publishes them,
+// marks published messages sent and schedules failed ones for a retry with exponential backoff.
+// Returns the number of messages processed, whether published or failed.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	const op = "dbpg.pgx-driver.outbox.ProcessBatch"
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	table := r.ident.Sanitize()
+	rows, err := tx.Query(ctx,
+		"SELECT id, topic, key, payload, headers, attempts, created_at FROM "+table+
+			" WHERE sent_at IS NULL AND next_attempt_at <= now() AND ($1 = 0 OR attempts < $1)"+
+			" ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED",
+		r.maxAttempts, r.batchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: select: %w", op, err)
+	}
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Message, error) {
+		var msg Message
+		err := row.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &msg.Headers, &msg.Attempts, &msg.CreatedAt)
+		return msg, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: select: %w", op, err)
+	}
+
+	sent := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		pubErr := r.publisher.Publish(ctx, msg)
+		if pubErr == nil {
+			sent = append(sent, msg.ID)
+			continue
+		}
+
+		attempts := msg.Attempts + 1
+		delay := r.retryDelay(attempts)
+		r.logger.LogAttrs(ctx, logger.WarnLevel, "outbox publish failed",
+			logger.String("op", op),
+			logger.Int64("id", msg.ID),
+			logger.String("topic", msg.Topic),
+			logger.Int("attempt", attempts),
+			logger.String("retry_after", delay.String()),
+			logger.Any("error", pubErr),
+		)
+
+		_, err := tx.Exec(ctx,
+			"UPDATE "+table+" SET attempts = $2, last_error = $3, next_attempt_at = $4 WHERE id = $1",
+			msg.ID, attempts, pubErr.Error(), time.Now().Add(delay),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: update failed message %d: %w", op, msg.ID, err)
+		}
+	}
+
+	if len(sent) > 0 {
+		if _, err := tx.Exec(ctx, "UPDATE "+table+" SET sent_at = now() WHERE id = ANY($1)", sent); err != nil {
+			return 0, fmt.Errorf("%s: mark sent: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return len(messages), nil
+}
+
+// Cleanup deletes messages sent more than Retention ago, in chunks to keep locks short.
+// Returns the number of deleted messages.
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	const op = "dbpg.pgx-driver.outbox.Cleanup"
+
+	if r.retention <= 0 {
+		return 0, nil
+	}
+
+	table := r.ident.Sanitize()
+	cutoff := time.Now().Add(-r.retention)
+
+	var total int64
+	for {
+		tag, err := r.pg.Exec(ctx,
+			"DELETE FROM "+table+" WHERE id IN (SELECT id FROM "+table+" WHERE sent_at < $1 LIMIT $2)",
+			cutoff, _cleanupBatchSize,
+		)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		total += tag.RowsAffected()
+		if tag.RowsAffected() < _cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// retryDelay returns the delay before the next attempt after the given number of failed attempts.
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.baseRetryDelay
+	for i := 1; i < attempts && delay < r.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, r.maxRetryDelay)
+}
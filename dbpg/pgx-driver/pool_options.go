@@ -0,0 +1,238 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrInvalidMinPoolSize is returned when MinPoolSize or MinIdleConns is negative or exceeds MaxPoolSize.
+	ErrInvalidMinPoolSize = errors.New("invalid minPoolSize: must be >= 0 and <= maxPoolSize")
+	// ErrInvalidMaxConnLifetime is returned when MaxConnLifetime < 0 or MaxConnLifetimeJitter < 0.
+	ErrInvalidMaxConnLifetime = errors.New("invalid max conn lifetime: must be >= 0")
+	// ErrInvalidMaxConnIdleTime is returned when MaxConnIdleTime < 0.
+	ErrInvalidMaxConnIdleTime = errors.New("invalid max conn idle time: must be >= 0")
+	// ErrInvalidHealthCheckPeriod is returned when HealthCheckPeriod < 0.
+	ErrInvalidHealthCheckPeriod = errors.New("invalid health check period: must be >= 0")
+	// ErrInvalidStatementCacheCapacity is returned when StatementCacheCapacity < 0.
+	ErrInvalidStatementCacheCapacity = errors.New("invalid statement cache capacity: must be >= 0")
+)
+
+// poolSettings holds optional pgxpool settings. Zero values keep the pgx defaults.
+type poolSettings struct {
+	minConns              int32
+	minIdleConns          int32
+	maxConnLifetime       time.Duration
+	maxConnLifetimeJitter time.Duration
+	maxConnIdleTime       time.Duration
+	healthCheckPeriod     time.Duration
+
+	queryExecMode          pgx.QueryExecMode
+	queryExecModeSet       bool
+	statementCacheCapacity int
+
+	runtimeParams map[string]string
+	afterConnect  []func(ctx context.Context, conn *pgx.Conn) error
+	beforeAcquire func(ctx context.Context, conn *pgx.Conn) bool
+}
+
+// MinPoolSize sets the minimum number of connections kept open in the pool.
+// The value must be non-negative and not greater than MaxPoolSize.
+func MinPoolSize(size int32) Option {
+	return func(p *Postgres) {
+		p.settings.minConns = size
+	}
+}
+
+// MinIdleConns sets the minimum number of idle connections kept in the pool
+// to avoid connection setup latency under load.
+func MinIdleConns(size int32) Option {
+	return func(p *Postgres) {
+		p.settings.minIdleConns = size
+	}
+}
+
+// MaxConnLifetime sets the duration since creation after which a connection is closed
+// (1 hour by default).
+func MaxConnLifetime(lifetime time.Duration) Option {
+	return func(p *Postgres) {
+		p.settings.maxConnLifetime = lifetime
+	}
+}
+
+// MaxConnLifetimeJitter sets a random duration added to MaxConnLifetime per connection,
+// so that connections are not all closed at the same time.
+func MaxConnLifetimeJitter(jitter time.Duration) Option {
+	return func(p *Postgres) {
+		p.settings.maxConnLifetimeJitter = jitter
+	}
+}
+
+// MaxConnIdleTime sets the duration after which an idle connection is closed
+// by the health check (30 minutes by default).
+func MaxConnIdleTime(idle time.Duration) Option {
+	return func(p *Postgres) {
+		p.settings.maxConnIdleTime = idle
+	}
+}
+
+// HealthCheckPeriod sets the interval between health checks of idle connections
+// (1 minute by default).
+func HealthCheckPeriod(period time.Duration) Option {
+	return func(p *Postgres) {
+		p.settings.healthCheckPeriod = period
+	}
+}
+
+// QueryExecMode sets how queries are executed and whether prepared statements are cached.
+// Behind PgBouncer in transaction pooling mode use pgx.QueryExecModeExec or
+// pgx.QueryExecModeSimpleProtocol, which do not rely on session-level prepared statements.
+func QueryExecMode(mode pgx.QueryExecMode) Option {
+	return func(p *Postgres) {
+		p.settings.queryExecMode = mode
+		p.settings.queryExecModeSet = true
+	}
+}
+
+// StatementCacheCapacity sets the maximum number of prepared statements cached per connection
+// (512 by default).
+func StatementCacheCapacity(capacity int) Option {
+	return func(p *Postgres) {
+		p.settings.statementCacheCapacity = capacity
+	}
+}
+
+// ApplicationName sets the application_name reported in pg_stat_activity.
+func ApplicationName(name string) Option {
+	return func(p *Postgres) {
+		p.setRuntimeParam("application_name", name)
+	}
+}
+
+// SearchPath sets the schema search path of every connection.
+func SearchPath(schemas ...string) Option {
+	return func(p *Postgres) {
+		quoted := make([]string, len(schemas))
+		for i, schema := range schemas {
+			quoted[i] = pgx.Identifier{schema}.Sanitize()
+		}
+		p.setRuntimeParam("search_path", strings.Join(quoted, ", "))
+	}
+}
+
+// AfterConnect adds a hook called after a connection is established and before it is added
+// to the pool. Hooks run in the order they were added; an error discards the connection.
+func AfterConnect(fn func(ctx context.Context, conn *pgx.Conn) error) Option {
+	return func(p *Postgres) {
+		p.settings.afterConnect = append(p.settings.afterConnect, fn)
+	}
+}
+
+// RegisterTypes loads custom types (enums, domains, composite and array types) by name
+// on every new connection and registers them in the connection's type map.
+func RegisterTypes(typeNames ...string) Option {
+	return AfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		types, err := conn.LoadTypes(ctx, typeNames)
+		if err != nil {
+			return fmt.Errorf("load types: %w", err)
+		}
+		conn.TypeMap().RegisterTypes(types)
+		return nil
+	})
+}
+
+// BeforeAcquire sets a hook validating a connection before it is acquired from the pool.
+// Returning false destroys the connection and acquires another one.
+func BeforeAcquire(fn func(ctx context.Context, conn *pgx.Conn) bool) Option {
+	return func(p *Postgres) {
+		p.settings.beforeAcquire = fn
+	}
+}
+
+// setRuntimeParam sets a run-time parameter sent on connection startup.
+func (p *Postgres) setRuntimeParam(name, value string) {
+	if p.settings.runtimeParams == nil {
+		p.settings.runtimeParams = make(map[string]string)
+	}
+	p.settings.runtimeParams[name] = value
+}
+
+// apply copies the configured settings to the pool configuration.
+func (s *poolSettings) apply(cfg *pgxpool.Config) {
+	if s.minConns > 0 {
+		cfg.MinConns = s.minConns
+	}
+	if s.minIdleConns > 0 {
+		cfg.MinIdleConns = s.minIdleConns
+	}
+	if s.maxConnLifetime > 0 {
+		cfg.MaxConnLifetime = s.maxConnLifetime
+	}
+	if s.maxConnLifetimeJitter > 0 {
+		cfg.MaxConnLifetimeJitter = s.maxConnLifetimeJitter
+	}
+	if s.maxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = s.maxConnIdleTime
+	}
+	if s.healthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = s.healthCheckPeriod
+	}
+
+	if s.queryExecModeSet {
+		cfg.ConnConfig.DefaultQueryExecMode = s.queryExecMode
+	}
+	if s.statementCacheCapacity > 0 {
+		cfg.ConnConfig.StatementCacheCapacity = s.statementCacheCapacity
+	}
+	for name, value := range s.runtimeParams {
+		cfg.ConnConfig.RuntimeParams[name] = value
+	}
+
+	if len(s.afterConnect) > 0 {
+		hooks := s.afterConnect
+		cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			for _, hook := range hooks {
+				if err := hook(ctx, conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if s.beforeAcquire != nil {
+		beforeAcquire := s.beforeAcquire
+		cfg.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+			return beforeAcquire(ctx, conn), nil
+		}
+	}
+}
+
+// validate checks the pool settings against the maximum pool size.
+func (s *poolSettings) validate(maxPoolSize int32) error {
+	if s.minConns < 0 || s.minConns > maxPoolSize || s.minIdleConns < 0 || s.minIdleConns > maxPoolSize {
+		return ErrInvalidMinPoolSize
+	}
+
+	if s.maxConnLifetime < 0 || s.maxConnLifetimeJitter < 0 {
+		return ErrInvalidMaxConnLifetime
+	}
+
+	if s.maxConnIdleTime < 0 {
+		return ErrInvalidMaxConnIdleTime
+	}
+
+	if s.healthCheckPeriod < 0 {
+		return ErrInvalidHealthCheckPeriod
+	}
+
+	if s.statementCacheCapacity < 0 {
+		return ErrInvalidStatementCacheCapacity
+	}
+	return nil
+}
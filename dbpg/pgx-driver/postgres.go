@@ -35,6 +35,32 @@ type Postgres struct {
 	baseRetryDelay time.Duration
 	maxRetryDelay  time.Duration
 	maxPoolSize    int32
+	tracer         *Tracer
+	settings       poolSettings
+
+	replicaDSNs          []string
+	replicaCheckInterval time.Duration
+	replicaCheckTimeout  time.Duration
+	replicas             *replicaSet
+
+	lockCheckInterval time.Duration
+}
+
+// PoolStats is a snapshot of connection pool statistics.
+type PoolStats struct {
+	AcquireCount            int64         // Cumulative count of successful acquires.
+	AcquireDuration         time.Duration // Total duration of all successful acquires.
+	AcquiredConns           int32         // Connections currently acquired.
+	CanceledAcquireCount    int64         // Cumulative count of acquires canceled by a context.
+	ConstructingConns       int32         // Connections currently being established.
+	EmptyAcquireCount       int64         // Cumulative count of acquires that waited for a connection.
+	EmptyAcquireWaitTime    time.Duration // Total time acquires waited for a connection.
+	IdleConns               int32         // Connections currently idle.
+	MaxConns                int32         // Maximum size of the pool.
+	TotalConns              int32         // Total connections in the pool.
+	NewConnsCount           int64         // Cumulative count of new connections opened.
+	MaxLifetimeDestroyCount int64         // Cumulative count of connections closed by MaxConnLifetime.
+	MaxIdleDestroyCount     int64         // Cumulative count of connections closed by MaxConnIdleTime.
 }
 
 // New creates and initializes a new Postgres client.
@@ -61,26 +87,64 @@ func New(dsn string, logger logger.Logger, opts ...Option) (*Postgres, error) {
 
 	pg.Builder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	poolConfig, err := pgxpool.ParseConfig(dsn)
+	poolConfig, err := pg.poolConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("%s: parse pool config: %w", op, err)
 	}
 
-	poolConfig.MaxConns = pg.maxPoolSize
+	pg.Pool, err = pg.connect(poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: create new pool: %w", op, err)
+	}
+
+	pg.logger.Info("postgresql connection successful")
+
+	if err := pg.connectReplicas(); err != nil {
+		pg.Pool.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pg, nil
+}
+
+// poolConfig parses the DSN and applies the client options to the pool configuration.
+func (p *Postgres) poolConfig(dsn string) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	currentBackoff := pg.baseRetryDelay
-	for attemptCount := 1; attemptCount <= pg.connAttempts; attemptCount++ {
-		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
+	poolConfig.MaxConns = p.maxPoolSize
+	p.settings.apply(poolConfig)
+	if p.tracer != nil {
+		poolConfig.ConnConfig.Tracer = p.tracer
+	}
+
+	return poolConfig, nil
+}
+
+// connect creates a pool for the configuration, retrying with exponential backoff and jitter.
+func (p *Postgres) connect(poolConfig *pgxpool.Config) (*pgxpool.Pool, error) {
+	const op = "dbpg.pgxdriver.New"
+
+	var (
+		pool *pgxpool.Pool
+		err  error
+	)
+	currentBackoff := p.baseRetryDelay
+	for attemptCount := 1; attemptCount <= p.connAttempts; attemptCount++ {
+		pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 		if err == nil {
-			return pg, nil
+			return pool, nil
 		}
 		//nolint:gosec
 		jitter := min(time.Duration(
 			rand.Int64N(int64(currentBackoff*_backoffMultiplier)),
-		), pg.maxRetryDelay)
+		), p.maxRetryDelay)
 
-		pg.logger.Info("postgresql connection attempt failed",
+		p.logger.Info("postgresql connection attempt failed",
 			"operation", op,
+			"host", poolConfig.ConnConfig.Host,
 			"attempt", attemptCount,
 			"retry_after", jitter.String(),
 			"error", err,
@@ -88,16 +152,11 @@ func New(dsn string, logger logger.Logger, opts ...Option) (*Postgres, error) {
 
 		time.Sleep(jitter)
 
-		nextBackoff := min(currentBackoff*_backoffMultiplier, pg.maxRetryDelay)
+		nextBackoff := min(currentBackoff*_backoffMultiplier, p.maxRetryDelay)
 		currentBackoff = nextBackoff
 	}
-	if err != nil {
-		return nil, fmt.Errorf("%s: create new pool: %w", op, err)
-	}
-
-	pg.logger.Info("postgresql connection successful")
 
-	return pg, nil
+	return nil, err
 }
 
 // Ping verifies the database connection by sending a lightweight ping request.
@@ -106,9 +165,20 @@ func (p *Postgres) Ping(ctx context.Context) error {
 	return p.Pool.Ping(ctx)
 }
 
-// Close gracefully shuts down the connection pool and logs the shutdown process.
+// Stats returns a snapshot of the connection pool statistics.
+func (p *Postgres) Stats() PoolStats {
+	if p.Pool == nil {
+		return PoolStats{}
+	}
+
+	return poolStats(p.Pool)
+}
+
+// Close gracefully shuts down the connection pool and replica pools and logs the shutdown process.
 // It is safe to call Close multiple times.
 func (p *Postgres) Close() {
+	p.closeReplicas()
+
 	if p.Pool != nil {
 		p.logger.Info("closing postgresql connection pool...")
 		p.Pool.Close()
@@ -139,3 +209,23 @@ func (p *Postgres) Update(table string) squirrel.UpdateBuilder {
 func (p *Postgres) Delete(from string) squirrel.DeleteBuilder {
 	return p.Builder.Delete(from)
 }
+
+// poolStats converts pgxpool statistics to PoolStats.
+func poolStats(pool *pgxpool.Pool) PoolStats {
+	s := pool.Stat()
+	return PoolStats{
+		AcquireCount:            s.AcquireCount(),
+		AcquireDuration:         s.AcquireDuration(),
+		AcquiredConns:           s.AcquiredConns(),
+		CanceledAcquireCount:    s.CanceledAcquireCount(),
+		ConstructingConns:       s.ConstructingConns(),
+		EmptyAcquireCount:       s.EmptyAcquireCount(),
+		EmptyAcquireWaitTime:    s.EmptyAcquireWaitTime(),
+		IdleConns:               s.IdleConns(),
+		MaxConns:                s.MaxConns(),
+		TotalConns:              s.TotalConns(),
+		NewConnsCount:           s.NewConnsCount(),
+		MaxLifetimeDestroyCount: s.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     s.MaxIdleDestroyCount(),
+	}
+}
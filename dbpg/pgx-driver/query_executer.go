@@ -31,21 +31,21 @@ type QueryExecuter interface {
 }
 
 // Query executes a query that returns rows, such as a SELECT.
-// Delegates to the underlying pgxpool.Pool.
+// Delegates to the underlying pgxpool.Pool, or to a replica if the context is marked with WithReadOnly.
 func (p *Postgres) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-	return p.Pool.Query(ctx, sql, args...)
+	return p.readPool(ctx).Query(withOperation(ctx, OpQuery), sql, args...)
 }
 
 // QueryRow executes a query expected to return at most one row.
-// Delegates to the underlying pgxpool.Pool.
+// Delegates to the underlying pgxpool.Pool, or to a replica if the context is marked with WithReadOnly.
 func (p *Postgres) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
-	return p.Pool.QueryRow(ctx, sql, args...)
+	return p.readPool(ctx).QueryRow(withOperation(ctx, OpQuery), sql, args...)
 }
 
 // Exec executes a non-query SQL statement (e.g., INSERT, UPDATE, DELETE).
 // Delegates to the underlying pgxpool.Pool.
 func (p *Postgres) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
-	return p.Pool.Exec(ctx, sql, args...)
+	return p.Pool.Exec(withOperation(ctx, OpExec), sql, args...)
 }
 
 // SendBatch sends a batch of queries to the server using pgx's batch protocol.
@@ -73,17 +73,17 @@ type TxQueryExecuter struct {
 
 // Query executes a query within a transaction.
 func (t *TxQueryExecuter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-	return t.Tx.Query(ctx, sql, args...)
+	return t.Tx.Query(withOperation(ctx, OpQuery), sql, args...)
 }
 
 // QueryRow executes a single-row query within a transaction.
 func (t *TxQueryExecuter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
-	return t.Tx.QueryRow(ctx, sql, args...)
+	return t.Tx.QueryRow(withOperation(ctx, OpQuery), sql, args...)
 }
 
 // Exec executes a non-query statement within a transaction.
 func (t *TxQueryExecuter) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
-	return t.Tx.Exec(ctx, sql, args...)
+	return t.Tx.Exec(withOperation(ctx, OpExec), sql, args...)
 }
 
 // SendBatch sends a batch of queries within a transaction.
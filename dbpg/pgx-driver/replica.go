@@ -0,0 +1,220 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/wb-go/wbf/internal/health"
+)
+
+const (
+	_defaultReplicaCheckInterval = 5 * time.Second
+	_defaultReplicaCheckTimeout  = time.Second
+)
+
+// ErrInvalidReplicaHealthCheck is returned when ReplicaHealthCheckInterval or ReplicaHealthCheckTimeout < 0.
+var ErrInvalidReplicaHealthCheck = errors.New("invalid replica health check: interval and timeout must be >= 0")
+
+// Replicas adds read replica pools. Each replica pool is created with the same options as
+// the primary pool. Replicas are pinged once in New and then periodically; unavailable replicas
+// are excluded from balancing until they recover.
+func Replicas(dsns ...string) Option {
+	return func(p *Postgres) {
+		p.replicaDSNs = append(p.replicaDSNs, dsns...)
+	}
+}
+
+// ReplicaHealthCheckInterval sets how often replicas are pinged (5 seconds by default).
+func ReplicaHealthCheckInterval(interval time.Duration) Option {
+	return func(p *Postgres) {
+		p.replicaCheckInterval = interval
+	}
+}
+
+// ReplicaHealthCheckTimeout limits a single replica ping (1 second by default).
+func ReplicaHealthCheckTimeout(timeout time.Duration) Option {
+	return func(p *Postgres) {
+		p.replicaCheckTimeout = timeout
+	}
+}
+
+// readOnlyKey is the context key marking read-only calls.
+type readOnlyKey struct{}
+
+// WithReadOnly returns a context whose Query and QueryRow calls on Postgres are sent
+// to a healthy replica. Without replicas, or if none is healthy, they go to the primary.
+// Transactions are not affected.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// isReadOnly reports whether the context is marked with WithReadOnly.
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// replicaSet balances calls across healthy replica pools in round-robin order.
+type replicaSet struct {
+	pools   []*pgxpool.Pool
+	health  *health.Checker
+	counter atomic.Uint64
+}
+
+// connectReplicas creates the replica pools and starts their health checks.
+func (p *Postgres) connectReplicas() error {
+	if len(p.replicaDSNs) == 0 {
+		return nil
+	}
+
+	rs := &replicaSet{
+		pools:  make([]*pgxpool.Pool, 0, len(p.replicaDSNs)),
+		health: health.NewChecker(len(p.replicaDSNs)),
+	}
+	for i, dsn := range p.replicaDSNs {
+		poolConfig, err := p.poolConfig(dsn)
+		if err == nil {
+			var pool *pgxpool.Pool
+			if pool, err = p.connect(poolConfig); err == nil {
+				rs.pools = append(rs.pools, pool)
+				continue
+			}
+		}
+
+		for _, pool := range rs.pools {
+			pool.Close()
+		}
+		return fmt.Errorf("create replica %d pool: %w", i, err)
+	}
+
+	interval, timeout := p.replicaCheckInterval, p.replicaCheckTimeout
+	if interval == 0 {
+		interval = _defaultReplicaCheckInterval
+	}
+	if timeout == 0 {
+		timeout = _defaultReplicaCheckTimeout
+	}
+	rs.health.Start(interval, timeout, func(ctx context.Context, idx int) bool {
+		return rs.pools[idx].Ping(ctx) == nil
+	})
+
+	p.replicas = rs
+	p.logger.Info("postgresql replicas connected", "replicas", len(rs.pools))
+
+	return nil
+}
+
+// closeReplicas stops health checks and closes the replica pools.
+func (p *Postgres) closeReplicas() {
+	if p.replicas == nil {
+		return
+	}
+
+	p.replicas.health.Stop()
+	for _, pool := range p.replicas.pools {
+		pool.Close()
+	}
+	p.replicas = nil
+}
+
+// Replica returns a QueryExecuter running every call on a healthy replica chosen
+// in round-robin order, or on the primary if there are no healthy replicas.
+// Use it for reads only: replicas reject writes.
+func (p *Postgres) Replica() QueryExecuter {
+	return &replicaExecuter{p: p}
+}
+
+// HealthyReplicas returns the number of replicas currently used for reads.
+func (p *Postgres) HealthyReplicas() int {
+	if p.replicas == nil {
+		return 0
+	}
+
+	return p.replicas.health.HealthyCount()
+}
+
+// ReplicaStats returns a snapshot of every replica pool's statistics, in the order of Replicas DSNs.
+func (p *Postgres) ReplicaStats() []PoolStats {
+	if p.replicas == nil {
+		return nil
+	}
+
+	stats := make([]PoolStats, len(p.replicas.pools))
+	for i, pool := range p.replicas.pools {
+		stats[i] = poolStats(pool)
+	}
+	return stats
+}
+
+// readPool returns the pool for a read: a healthy replica if the context is marked
+// with WithReadOnly, otherwise the primary.
+func (p *Postgres) readPool(ctx context.Context) *pgxpool.Pool {
+	if isReadOnly(ctx) {
+		return p.replicaPool()
+	}
+	return p.Pool
+}
+
+// replicaPool returns the next healthy replica pool, or the primary pool if none is healthy.
+func (p *Postgres) replicaPool() *pgxpool.Pool {
+	if p.replicas != nil {
+		if pool, ok := p.replicas.pick(); ok {
+			return pool
+		}
+	}
+	return p.Pool
+}
+
+// pick returns the next healthy replica pool in round-robin order.
+func (rs *replicaSet) pick() (*pgxpool.Pool, bool) {
+	n := uint64(len(rs.pools))
+	start := rs.counter.Add(1) - 1
+	for i := range n {
+		idx := (start + i) % n
+		if rs.health.Healthy(int(idx)) {
+			return rs.pools[idx], true
+		}
+	}
+	return nil, false
+}
+
+// replicaExecuter implements QueryExecuter on top of the replica pools.
+type replicaExecuter struct {
+	p *Postgres
+}
+
+// Query executes a query on a replica.
+func (r *replicaExecuter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.p.replicaPool().Query(withOperation(ctx, OpQuery), sql, args...)
+}
+
+// QueryRow executes a single-row query on a replica.
+func (r *replicaExecuter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.p.replicaPool().QueryRow(withOperation(ctx, OpQuery), sql, args...)
+}
+
+// Exec executes a statement on a replica.
+func (r *replicaExecuter) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return r.p.replicaPool().Exec(withOperation(ctx, OpExec), sql, args...)
+}
+
+// SendBatch sends a batch of queries to a replica.
+func (r *replicaExecuter) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return r.p.replicaPool().SendBatch(ctx, b)
+}
+
+// CopyFrom performs a COPY FROM operation on a replica.
+func (r *replicaExecuter) CopyFrom(
+	ctx context.Context,
+	tableName pgx.Identifier,
+	columnNames []string,
+	rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	return r.p.replicaPool().CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
@@ -0,0 +1,27 @@
+package pgxdriver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+)
+
+func TestNew_ReplicasEjectedWhenUnreachable(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	pg, err := pgxdriver.New(unreachableDSN, log,
+		pgxdriver.Replicas(unreachableDSN, unreachableDSN),
+		pgxdriver.ReplicaHealthCheckInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	// Replicas are checked before New returns.
+	assert.Len(t, pg.ReplicaStats(), 2)
+	assert.Equal(t, 0, pg.HealthyReplicas())
+}
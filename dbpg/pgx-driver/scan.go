@@ -0,0 +1,56 @@
+package pgxdriver
+
+import (
+	"github.com/jackc/pgx/v5"
+
+	"github.com/wb-go/wbf/dbpg/scan"
+)
+
+// ErrNotFound is returned by ScanOne when the query returned no rows.
+// It replaces pgx.ErrNoRows for typed scanning.
+var ErrNotFound = scan.ErrNotFound
+
+// ScanAll scans all rows into a slice of T and closes rows.
+// Struct fields are matched to columns by the `db:"column"` tag or the lower-cased field name;
+// embedded structs are flattened and pointer fields accept NULL.
+// Non-struct T is scanned from a single-column result.
+func ScanAll[T any](rows pgx.Rows) ([]T, error) {
+	return scan.All[T](pgxRows{rows: rows})
+}
+
+// ScanOne scans the first row into T and closes rows.
+// It returns ErrNotFound if the query returned no rows.
+func ScanOne[T any](rows pgx.Rows) (T, error) {
+	return scan.One[T](pgxRows{rows: rows})
+}
+
+// ScanOptional scans the first row into T and closes rows.
+// It returns nil without an error if the query returned no rows.
+func ScanOptional[T any](rows pgx.Rows) (*T, error) {
+	return scan.Optional[T](pgxRows{rows: rows})
+}
+
+// pgxRows adapts pgx.Rows to scan.Rows.
+type pgxRows struct {
+	rows pgx.Rows
+}
+
+func (r pgxRows) Columns() ([]string, error) {
+	fields := r.rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+	return columns, nil
+}
+
+func (r pgxRows) Next() bool { return r.rows.Next() }
+
+func (r pgxRows) Scan(dest ...any) error { return r.rows.Scan(dest...) }
+
+func (r pgxRows) Err() error { return r.rows.Err() }
+
+func (r pgxRows) Close() error {
+	r.rows.Close()
+	return r.rows.Err()
+}
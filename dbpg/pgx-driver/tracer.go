@@ -0,0 +1,423 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_defaultSlowQueryThreshold = 500 * time.Millisecond
+	_defaultMaxSQLLength       = 2048
+)
+
+var (
+	// ErrInvalidSlowQueryThreshold is returned when SlowQueryThreshold < 0.
+	ErrInvalidSlowQueryThreshold = errors.New("invalid slow query threshold: must be >= 0")
+	// ErrInvalidMaxSQLLength is returned when MaxSQLLength <= 0.
+	ErrInvalidMaxSQLLength = errors.New("invalid max SQL length: must be > 0")
+	// ErrInvalidHistogramBuckets is returned when HistogramBuckets are empty, not positive or not ascending.
+	ErrInvalidHistogramBuckets = errors.New("invalid histogram buckets: must be positive and strictly ascending")
+)
+
+// defaultHistogramBuckets are the upper bounds of the default duration histogram.
+var defaultHistogramBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Operation identifies the kind of traced database call.
+type Operation string
+
+const (
+	// OpQuery is a Query or QueryRow call.
+	OpQuery Operation = "query"
+	// OpExec is an Exec call.
+	OpExec Operation = "exec"
+	// OpSendBatch is a SendBatch call.
+	OpSendBatch Operation = "send_batch"
+	// OpCopyFrom is a CopyFrom call.
+	OpCopyFrom Operation = "copy_from"
+)
+
+// QueryEvent describes a completed database call. Argument values are never recorded.
+type QueryEvent struct {
+	Operation    Operation
+	SQL          string // Whitespace-collapsed and truncated to MaxSQLLength; empty for batches.
+	Args         int    // Number of arguments.
+	Statements   int    // Number of statements; greater than one for batches.
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+}
+
+// HistogramBucket is a duration histogram bucket. Count is the number of calls that took
+// longer than the previous bucket's bound and at most UpperBound; the last bucket has
+// UpperBound math.MaxInt64.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// OperationStats contains cumulative metrics of one operation kind.
+type OperationStats struct {
+	Calls         uint64
+	Errors        uint64
+	SlowCalls     uint64
+	RowsAffected  int64
+	TotalDuration time.Duration
+	Histogram     []HistogramBucket
+}
+
+// TracerStats is a snapshot of Tracer metrics per operation kind.
+type TracerStats struct {
+	Query     OperationStats
+	Exec      OperationStats
+	SendBatch OperationStats
+	CopyFrom  OperationStats
+}
+
+// Tracer implements pgx.QueryTracer, pgx.BatchTracer and pgx.CopyFromTracer.
+// It records the duration, rows affected and errors of every call, logs calls slower
+// than the slow query threshold and keeps per-operation counters and duration histograms.
+// Attach it with the QueryTracer option; calls made inside transactions are traced too.
+type Tracer struct {
+	logger        logger.Logger
+	slowThreshold time.Duration
+	maxSQLLength  int
+	buckets       []time.Duration
+	onQuery       func(ctx context.Context, event QueryEvent)
+
+	query     operationMetrics
+	exec      operationMetrics
+	sendBatch operationMetrics
+	copyFrom  operationMetrics
+}
+
+var (
+	_ pgx.QueryTracer    = (*Tracer)(nil)
+	_ pgx.BatchTracer    = (*Tracer)(nil)
+	_ pgx.CopyFromTracer = (*Tracer)(nil)
+)
+
+// TracerOption represents a functional configuration option for the Tracer.
+type TracerOption func(*Tracer)
+
+// SlowQueryThreshold sets the duration above which calls are logged as slow (500ms by default).
+// Zero disables the slow query log.
+func SlowQueryThreshold(threshold time.Duration) TracerOption {
+	return func(t *Tracer) {
+		t.slowThreshold = threshold
+	}
+}
+
+// MaxSQLLength sets the maximum length in bytes of SQL text in logs and events (2048 by default).
+// Longer text is cut at a character boundary.
+func MaxSQLLength(length int) TracerOption {
+	return func(t *Tracer) {
+		t.maxSQLLength = length
+	}
+}
+
+// HistogramBuckets sets the upper bounds of the duration histogram buckets.
+// Bounds must be positive and strictly ascending.
+func HistogramBuckets(bounds ...time.Duration) TracerOption {
+	return func(t *Tracer) {
+		t.buckets = bounds
+	}
+}
+
+// OnQuery sets a callback invoked after every traced call,
+// e.g. to export metrics or spans to an external system.
+func OnQuery(fn func(ctx context.Context, event QueryEvent)) TracerOption {
+	return func(t *Tracer) {
+		t.onQuery = fn
+	}
+}
+
+// NewTracer creates a Tracer logging slow queries through the logger.
+// Returns an error if validation of the options fails.
+func NewTracer(logger logger.Logger, opts ...TracerOption) (*Tracer, error) {
+	const op = "dbpg.pgxdriver.NewTracer"
+
+	t := &Tracer{
+		logger:        logger,
+		slowThreshold: _defaultSlowQueryThreshold,
+		maxSQLLength:  _defaultMaxSQLLength,
+		buckets:       defaultHistogramBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: validation: %w", op, err)
+	}
+
+	t.buckets = slices.Clone(t.buckets)
+	for _, m := range []*operationMetrics{&t.query, &t.exec, &t.sendBatch, &t.copyFrom} {
+		m.histogram = make([]atomic.Uint64, len(t.buckets)+1)
+	}
+
+	return t, nil
+}
+
+// Stats returns a snapshot of the collected metrics.
+func (t *Tracer) Stats() TracerStats {
+	return TracerStats{
+		Query:     t.query.snapshot(t.buckets),
+		Exec:      t.exec.snapshot(t.buckets),
+		SendBatch: t.sendBatch.snapshot(t.buckets),
+		CopyFrom:  t.copyFrom.snapshot(t.buckets),
+	}
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceKey{}, &traceData{
+		operation:  operationFrom(ctx),
+		sql:        data.SQL,
+		args:       len(data.Args),
+		statements: 1,
+		start:      time.Now(),
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	td, ok := ctx.Value(traceKey{}).(*traceData)
+	if !ok {
+		return
+	}
+	if td.operation == "" {
+		td.operation = OpExec
+		if data.CommandTag.Select() {
+			td.operation = OpQuery
+		}
+	}
+	td.rows = data.CommandTag.RowsAffected()
+	t.finish(ctx, td, data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return context.WithValue(ctx, traceKey{}, &traceData{
+		operation:  OpSendBatch,
+		statements: data.Batch.Len(),
+		start:      time.Now(),
+	})
+}
+
+// TraceBatchQuery implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if td, ok := ctx.Value(traceKey{}).(*traceData); ok {
+		td.rows += data.CommandTag.RowsAffected()
+		td.args += len(data.Args)
+	}
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	if td, ok := ctx.Value(traceKey{}).(*traceData); ok {
+		t.finish(ctx, td, data.Err)
+	}
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromStart(
+	ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceCopyFromStartData,
+) context.Context {
+	return context.WithValue(ctx, traceKey{}, &traceData{
+		operation:  OpCopyFrom,
+		sql:        "COPY " + data.TableName.Sanitize() + " (" + strings.Join(data.ColumnNames, ", ") + ") FROM STDIN",
+		statements: 1,
+		start:      time.Now(),
+	})
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if td, ok := ctx.Value(traceKey{}).(*traceData); ok {
+		td.rows = data.CommandTag.RowsAffected()
+		t.finish(ctx, td, data.Err)
+	}
+}
+
+// finish records a completed call, logs it if it is slow and invokes the OnQuery callback.
+func (t *Tracer) finish(ctx context.Context, td *traceData, err error) {
+	event := QueryEvent{
+		Operation:    td.operation,
+		SQL:          t.redact(td.sql),
+		Args:         td.args,
+		Statements:   td.statements,
+		Duration:     time.Since(td.start),
+		RowsAffected: td.rows,
+		Err:          err,
+	}
+	slow := t.slowThreshold > 0 && event.Duration >= t.slowThreshold
+
+	t.metrics(event.Operation).record(t.buckets, event, slow)
+
+	if slow && t.logger != nil {
+		attrs := []logger.Attr{
+			logger.String("operation", string(event.Operation)),
+			logger.String("sql", event.SQL),
+			logger.Int("args", event.Args),
+			logger.Int("statements", event.Statements),
+			logger.Duration("duration", event.Duration),
+			logger.Int64("rows_affected", event.RowsAffected),
+		}
+		if err != nil {
+			attrs = append(attrs, logger.Any("error", err))
+		}
+		t.logger.LogAttrs(ctx, logger.WarnLevel, "slow query", attrs...)
+	}
+
+	if t.onQuery != nil {
+		t.onQuery(ctx, event)
+	}
+}
+
+// redact collapses whitespace and truncates the SQL text. Argument values are
+// sent separately from the SQL and are never included.
+func (t *Tracer) redact(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > t.maxSQLLength {
+		// Cut at a rune boundary so that a multi-byte character is never split.
+		cut := t.maxSQLLength
+		for cut > 0 && !utf8.RuneStart(sql[cut]) {
+			cut--
+		}
+		return sql[:cut] + "..."
+	}
+	return sql
+}
+
+// metrics returns the metrics of the operation kind.
+func (t *Tracer) metrics(op Operation) *operationMetrics {
+	switch op {
+	case OpExec:
+		return &t.exec
+	case OpSendBatch:
+		return &t.sendBatch
+	case OpCopyFrom:
+		return &t.copyFrom
+	case OpQuery:
+	}
+	return &t.query
+}
+
+// validate checks that all Tracer configuration parameters are valid.
+func (t *Tracer) validate() error {
+	if t.slowThreshold < 0 {
+		return ErrInvalidSlowQueryThreshold
+	}
+
+	if t.maxSQLLength <= 0 {
+		return ErrInvalidMaxSQLLength
+	}
+
+	if len(t.buckets) == 0 {
+		return ErrInvalidHistogramBuckets
+	}
+	for i, b := range t.buckets {
+		if b <= 0 || (i > 0 && b <= t.buckets[i-1]) {
+			return ErrInvalidHistogramBuckets
+		}
+	}
+	return nil
+}
+
+// operationMetrics holds lock-free counters of one operation kind.
+type operationMetrics struct {
+	calls     atomic.Uint64
+	errors    atomic.Uint64
+	slow      atomic.Uint64
+	rows      atomic.Int64
+	duration  atomic.Int64
+	histogram []atomic.Uint64 // One counter per bucket plus the overflow bucket.
+}
+
+func (m *operationMetrics) record(buckets []time.Duration, event QueryEvent, slow bool) {
+	m.calls.Add(1)
+	if event.Err != nil {
+		m.errors.Add(1)
+	}
+	if slow {
+		m.slow.Add(1)
+	}
+	m.rows.Add(event.RowsAffected)
+	m.duration.Add(int64(event.Duration))
+
+	idx, _ := slices.BinarySearch(buckets, event.Duration)
+	m.histogram[idx].Add(1)
+}
+
+func (m *operationMetrics) snapshot(buckets []time.Duration) OperationStats {
+	histogram := make([]HistogramBucket, len(m.histogram))
+	for i := range m.histogram {
+		bound := time.Duration(math.MaxInt64)
+		if i < len(buckets) {
+			bound = buckets[i]
+		}
+		histogram[i] = HistogramBucket{UpperBound: bound, Count: m.histogram[i].Load()}
+	}
+
+	return OperationStats{
+		Calls:         m.calls.Load(),
+		Errors:        m.errors.Load(),
+		SlowCalls:     m.slow.Load(),
+		RowsAffected:  m.rows.Load(),
+		TotalDuration: time.Duration(m.duration.Load()),
+		Histogram:     histogram,
+	}
+}
+
+// traceKey is the context key of the traceData of a call in progress.
+type traceKey struct{}
+
+// traceData is the state of a call in progress.
+type traceData struct {
+	operation  Operation
+	sql        string
+	args       int
+	statements int
+	rows       int64
+	start      time.Time
+}
+
+// operationKey is the context key of the Operation set by QueryExecuter methods.
+type operationKey struct{}
+
+// withOperation marks ctx with the kind of call about to be made, so that the Tracer can
+// tell Query from Exec. Calls made directly on the pool are classified by their command tag.
+func withOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// operationFrom returns the Operation set by withOperation, or an empty string.
+func operationFrom(ctx context.Context) Operation {
+	op, _ := ctx.Value(operationKey{}).(Operation)
+	return op
+}
@@ -0,0 +1,89 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+func TestTracer_RecordsCalls(t *testing.T) {
+	var events []pgxdriver.QueryEvent
+	tracer, err := pgxdriver.NewTracer(nil,
+		pgxdriver.SlowQueryThreshold(10*time.Millisecond),
+		pgxdriver.HistogramBuckets(5*time.Millisecond, time.Second),
+		pgxdriver.OnQuery(func(_ context.Context, e pgxdriver.QueryEvent) {
+			events = append(events, e)
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "UPDATE users\n\tSET name = $1\n\tWHERE id = $2",
+		Args: []any{"secret", 1},
+	})
+	time.Sleep(15 * time.Millisecond)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 2")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	batch := &pgx.Batch{}
+	batch.Queue("INSERT INTO t VALUES ($1)", 1)
+	batch.Queue("INSERT INTO t VALUES ($1)", 2)
+	ctx = tracer.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{Batch: batch})
+	for range 2 {
+		tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{CommandTag: pgconn.NewCommandTag("INSERT 0 1")})
+	}
+	tracer.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{})
+
+	require.Len(t, events, 3)
+	assert.Equal(t, pgxdriver.OpExec, events[0].Operation)
+	assert.Equal(t, "UPDATE users SET name = $1 WHERE id = $2", events[0].SQL)
+	assert.Equal(t, 2, events[0].Args)
+	assert.Equal(t, int64(2), events[0].RowsAffected)
+	assert.Equal(t, 2, events[2].Statements)
+
+	stats := tracer.Stats()
+	assert.Equal(t, uint64(2), stats.Exec.Calls)
+	assert.Equal(t, uint64(1), stats.Exec.Errors)
+	assert.Equal(t, uint64(1), stats.Exec.SlowCalls)
+	assert.Equal(t, uint64(1), stats.SendBatch.Calls)
+	assert.Equal(t, int64(2), stats.SendBatch.RowsAffected)
+	require.Len(t, stats.Exec.Histogram, 3)
+	assert.Equal(t, uint64(1), stats.Exec.Histogram[0].Count)
+	assert.Equal(t, uint64(1), stats.Exec.Histogram[1].Count)
+}
+
+func TestTracer_TruncatesAtRuneBoundary(t *testing.T) {
+	var event pgxdriver.QueryEvent
+	tracer, err := pgxdriver.NewTracer(nil,
+		pgxdriver.MaxSQLLength(9),
+		pgxdriver.OnQuery(func(_ context.Context, e pgxdriver.QueryEvent) {
+			event = e
+		}),
+	)
+	require.NoError(t, err)
+
+	// The first 9 bytes end in the middle of "é".
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 'été'"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	assert.Equal(t, "SELECT '...", event.SQL)
+	assert.True(t, utf8.ValidString(event.SQL))
+}
+
+func TestNewTracer_Validation(t *testing.T) {
+	_, err := pgxdriver.NewTracer(nil, pgxdriver.HistogramBuckets(time.Second, time.Millisecond))
+	require.ErrorIs(t, err, pgxdriver.ErrInvalidHistogramBuckets)
+
+	_, err = pgxdriver.NewTracer(nil, pgxdriver.SlowQueryThreshold(-time.Second))
+	require.ErrorIs(t, err, pgxdriver.ErrInvalidSlowQueryThreshold)
+}
@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// txKey is the context key of the active transaction.
+type txKey struct{}
+
+// txState is the active transaction stored in the context.
+type txState struct {
+	pool  *pgxdriver.Postgres
+	tx    pgx.Tx
+	hooks *txHooks
+}
+
+// withTx returns a context carrying the transaction started on pool and its hooks.
+func withTx(ctx context.Context, pool *pgxdriver.Postgres, tx pgx.Tx, hooks *txHooks) context.Context {
+	return context.WithValue(ctx, txKey{}, &txState{pool: pool, tx: tx, hooks: hooks})
+}
+
+// txFromContext returns the active transaction started on pool, if any.
+func txFromContext(ctx context.Context, pool *pgxdriver.Postgres) (*txState, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok || state.pool != pool {
+		return nil, false
+	}
+	return state, true
+}
+
+// Executor returns the QueryExecuter of the transaction active in the context, started by
+// a Manager on pg, or pg itself outside a transaction. Repositories use it to join the
+// caller's transaction without passing the executer explicitly:
+//
+//	func (r *Repo) Create(ctx context.Context, u User) error {
+//		_, err := transaction.Executor(ctx, r.pg).Exec(ctx, "INSERT ...", u.Name)
+//		return err
+//	}
+func Executor(ctx context.Context, pg *pgxdriver.Postgres) pgxdriver.QueryExecuter {
+	if state, ok := txFromContext(ctx, pg); ok {
+		return &pgxdriver.TxQueryExecuter{Tx: state.tx}
+	}
+	return pg
+}
+
+// InTransaction reports whether a transaction started by a Manager on pg is active in the context.
+func InTransaction(ctx context.Context, pg *pgxdriver.Postgres) bool {
+	_, ok := txFromContext(ctx, pg)
+	return ok
+}
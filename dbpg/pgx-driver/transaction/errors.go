@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/jackc/pgconn"
+	"github.com/wb-go/wbf/dbpg/pgerr"
 )
 
 var (
@@ -21,7 +21,8 @@ var (
 )
 
 // HandleError wraps a raw error with contextual information and maps PostgreSQL error codes
-// to semantic, user-friendly error types.
+// to semantic, user-friendly error types. PostgreSQL errors are wrapped as *pgerr.Error,
+// so they also match the pgerr sentinels.
 // It takes the operation name (e.g., "transfer_funds"), a step description (e.g., "execute"),
 // and the original error, then returns a wrapped error suitable for logging or upstream handling.
 // If the input error is nil, it returns nil.
@@ -38,30 +39,32 @@ func HandleError(operation, step string, err error) error {
 		return fmt.Errorf("%s: %s: canceled: %w", operation, step, err)
 	}
 
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		switch pgErr.Code {
-		case "40P01":
+	if pgErr, ok := pgerr.From(err); ok {
+		err = pgerr.Classify(err)
+		switch pgErr.Kind {
+		case pgerr.KindDeadlock:
 			return fmt.Errorf("%s: %s: deadlock: %w", operation, step, err)
-		case "40001":
+		case pgerr.KindSerializationFailure:
 			return fmt.Errorf("%s: %s: serialization failure: %w", operation, step, err)
-		case "57014":
+		case pgerr.KindQueryCanceled:
 			return fmt.Errorf("%s: %s: statement timeout: %w", operation, step, err)
-		case "55P03":
+		case pgerr.KindLockNotAvailable:
 			return fmt.Errorf("%s: %s: lock timeout: %w", operation, step, err)
-		case "23505":
+		case pgerr.KindUniqueViolation:
 			return fmt.Errorf(
-				"%s: %s: unique constraint violation: %w",
+				"%s: %s: unique constraint violation: %w: %w",
 				operation,
 				step,
 				ErrConflictingData,
+				err,
 			)
-		case "23503":
+		case pgerr.KindForeignKeyViolation:
 			return fmt.Errorf(
-				"%s: %s: foreign key violation: %w",
+				"%s: %s: foreign key violation: %w: %w",
 				operation,
 				step,
 				ErrInvalidData,
+				err,
 			)
 		}
 	}
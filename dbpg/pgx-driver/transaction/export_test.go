@@ -0,0 +1,29 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+)
+
+// NewManagerWithBegin creates a Manager starting transactions with begin instead of the pool.
+func NewManagerWithBegin(
+	pool *pgxdriver.Postgres,
+	logger logger.Logger,
+	begin func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error),
+	opts ...Option,
+) (Manager, error) {
+	tm, err := NewManager(pool, logger, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tm.(*manager).begin = begin
+	return tm, nil
+}
+
+// WithTx returns a context carrying tx as a transaction started by a Manager on pool.
+func WithTx(ctx context.Context, pool *pgxdriver.Postgres, tx pgx.Tx) context.Context {
+	return withTx(ctx, pool, tx, &txHooks{})
+}
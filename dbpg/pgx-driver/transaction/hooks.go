@@ -0,0 +1,115 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// ErrNoTransaction is returned by AfterCommit and AfterRollback when the context
+// does not carry a transaction started by a Manager.
+var ErrNoTransaction = errors.New("no transaction in context")
+
+// Hook is a function run after a transaction ends. Errors are logged by the Manager.
+type Hook func(ctx context.Context) error
+
+// AfterCommit registers fn to run after the transaction in the context commits, e.g. to
+// invalidate a cache or publish a metric. Hooks of retried attempts are discarded, so fn runs
+// at most once, only for the attempt that finally commits. A hook registered within a savepoint
+// that is rolled back is discarded. Use the context passed by RunInTransaction.
+func AfterCommit(ctx context.Context, fn Hook) error {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	state.hooks.add(&state.hooks.afterCommit, fn)
+	return nil
+}
+
+// AfterRollback registers fn to run after the transaction in the context finally fails, once
+// no more retries are made. A hook registered within a savepoint runs when the savepoint is
+// rolled back as well, after the whole transaction ends. Use the context passed by RunInTransaction.
+func AfterRollback(ctx context.Context, fn Hook) error {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	state.hooks.add(&state.hooks.afterRollback, fn)
+	return nil
+}
+
+// txHooks holds the hooks registered within a transaction or savepoint.
+type txHooks struct {
+	mu            sync.Mutex
+	afterCommit   []Hook
+	afterRollback []Hook
+	// afterEnd holds hooks of rolled back savepoints, run whatever the transaction outcome.
+	afterEnd []Hook
+}
+
+// add appends fn to the list.
+func (h *txHooks) add(list *[]Hook, fn Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	*list = append(*list, fn)
+}
+
+// release moves the hooks of a released savepoint to its parent.
+func (h *txHooks) release(sp *txHooks) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.afterCommit = append(h.afterCommit, sp.afterCommit...)
+	h.afterRollback = append(h.afterRollback, sp.afterRollback...)
+	h.afterEnd = append(h.afterEnd, sp.afterEnd...)
+}
+
+// rollback discards the commit hooks of a rolled back savepoint and schedules
+// its rollback hooks to run when the parent transaction ends.
+func (h *txHooks) rollback(sp *txHooks) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.afterEnd = append(h.afterEnd, sp.afterEnd...)
+	h.afterEnd = append(h.afterEnd, sp.afterRollback...)
+}
+
+// committed returns the hooks to run after a commit.
+func (h *txHooks) committed() []Hook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append(append([]Hook(nil), h.afterEnd...), h.afterCommit...)
+}
+
+// rolledBack returns the hooks to run after a final rollback.
+func (h *txHooks) rolledBack() []Hook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append(append([]Hook(nil), h.afterEnd...), h.afterRollback...)
+}
+
+// runHooks runs the hooks in registration order and logs their errors.
+func (tm *manager) runHooks(ctx context.Context, tsName string, hooks []Hook) {
+	const op = "dbpg.pgx-driver.transaction.runHooks"
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			tm.logger.LogAttrs(ctx, logger.ErrorLevel, "transaction hook failed",
+				logger.String("op", op),
+				logger.String("transaction", tsName),
+				logger.Any("error", err),
+			)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+// hooksManager returns a manager starting fake transactions that retries up to 3 attempts.
+func hooksManager(t *testing.T) (transaction.Manager, *txLog) {
+	t.Helper()
+
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	txs := &txLog{}
+	tm, err := transaction.NewManagerWithBegin(&pgxdriver.Postgres{}, log, txs.begin(),
+		transaction.MaxAttempts(3),
+		transaction.BaseRetryDelay(time.Millisecond),
+		transaction.MaxRetryDelay(time.Millisecond),
+	)
+	require.NoError(t, err)
+	return tm, txs
+}
+
+// register adds an AfterCommit and an AfterRollback hook recording their run to txs.
+func register(t *testing.T, ctx context.Context, txs *txLog, name string) {
+	t.Helper()
+
+	require.NoError(t, transaction.AfterCommit(ctx, func(context.Context) error {
+		txs.add("after commit " + name)
+		return nil
+	}))
+	require.NoError(t, transaction.AfterRollback(ctx, func(context.Context) error {
+		txs.add("after rollback " + name)
+		return nil
+	}))
+}
+
+func TestHooks_RetriedAttemptDropped(t *testing.T) {
+	tm, txs := hooksManager(t)
+
+	attempt := 0
+	err := tm.RunInTransaction(context.Background(), "retry", func(ctx context.Context) error {
+		attempt++
+		register(t, ctx, txs, "attempt "+strconv.Itoa(attempt))
+		if attempt == 1 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"begin tx1", "rollback tx1",
+		"begin tx2", "commit tx2",
+		"after commit attempt 2",
+	}, txs.Events())
+}
+
+func TestHooks_FinalFailureRunsLastAttemptRollbackHooks(t *testing.T) {
+	tm, txs := hooksManager(t)
+
+	attempt := 0
+	err := tm.RunInTransaction(context.Background(), "retry", func(ctx context.Context) error {
+		attempt++
+		register(t, ctx, txs, "attempt "+strconv.Itoa(attempt))
+		return &pgconn.PgError{Code: "40001"}
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"begin tx1", "rollback tx1",
+		"begin tx2", "rollback tx2",
+		"begin tx3", "rollback tx3",
+		"after rollback attempt 3",
+	}, txs.Events())
+}
+
+func TestHooks_RolledBackSavepoint(t *testing.T) {
+	tm, txs := hooksManager(t)
+	nested := transaction.TxOptions{Propagation: transaction.Nested}
+	errFail := errors.New("fail")
+
+	err := tm.RunInTransaction(context.Background(), "outer", func(ctx context.Context) error {
+		register(t, ctx, txs, "outer")
+		err := tm.RunInTransactionWithOptions(ctx, "savepoint", nested, func(ctx context.Context) error {
+			register(t, ctx, txs, "savepoint")
+			return errFail
+		})
+		require.ErrorIs(t, err, errFail)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The commit hook of the savepoint is discarded; its rollback hook runs once the
+	// transaction ends, before the hooks of the committed transaction.
+	assert.Equal(t, []string{
+		"begin tx1", "savepoint tx1/sp", "rollback tx1/sp", "commit tx1",
+		"after rollback savepoint", "after commit outer",
+	}, txs.Events())
+}
+
+func TestHooks_ReleasedSavepointFollowsTransaction(t *testing.T) {
+	tm, txs := hooksManager(t)
+	nested := transaction.TxOptions{Propagation: transaction.Nested}
+	errFail := errors.New("fail")
+
+	err := tm.RunInTransaction(context.Background(), "outer", func(ctx context.Context) error {
+		err := tm.RunInTransactionWithOptions(ctx, "released", nested, func(ctx context.Context) error {
+			register(t, ctx, txs, "released")
+			// Hooks of savepoints nested in a released one follow it.
+			return tm.RunInTransactionWithOptions(ctx, "inner", nested, func(ctx context.Context) error {
+				register(t, ctx, txs, "inner")
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+
+	assert.Equal(t, []string{
+		"begin tx1", "savepoint tx1/sp", "savepoint tx1/sp/sp", "commit tx1/sp/sp", "commit tx1/sp",
+		"rollback tx1",
+		"after rollback released", "after rollback inner",
+	}, txs.Events())
+}
@@ -9,8 +9,8 @@ import (
 	"math/rand/v2"
 	"time"
 
-	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v5"
+	"github.com/wb-go/wbf/dbpg/pgerr"
 	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
 	"github.com/wb-go/wbf/logger"
 )
@@ -21,20 +21,63 @@ const (
 	_defaultMaxRetryDelay  = 100 * time.Millisecond
 
 	_backoffMultiplier = 2
+
+	_defaultIsoLevel = pgx.ReadCommitted
 )
 
 // Manager defines the interface for executing functions within a retriable database transaction.
+//
+// The active transaction is stored in the context: a call made with a context carrying
+// a transaction of the same pool joins it, starts a savepoint or starts an independent
+// transaction according to TxOptions.Propagation. Only a call that starts a transaction
+// retries it; joined calls and savepoints return errors to the enclosing call.
 type Manager interface {
 	// ExecuteInTransaction runs the given function inside a PostgreSQL transaction.
 	// If the transaction fails due to a retryable error (e.g., serialization failure, deadlock),
 	// it will be retried up to maxAttempts times with exponential backoff and jitter.
 	// The tsName parameter is used for logging and observability.
 	// Returns the last error if all attempts fail, or nil on success.
+	//
+	// fn receives only the executer, not a context carrying the transaction. A Manager call
+	// made inside fn with the caller's ctx therefore does not see this transaction and starts
+	// an independent one on another connection.
+	//
+	// Deprecated: use RunInTransaction, whose fn receives a context carrying the transaction:
+	// obtain the executer with Executor, and nested Manager calls join the transaction.
 	ExecuteInTransaction(
 		ctx context.Context,
 		tsName string,
 		fn func(tx pgxdriver.QueryExecuter) error,
 	) error
+
+	// ExecuteInTransactionWithOptions works like ExecuteInTransaction, starting the transaction
+	// with the given isolation level and access modes. With pgx.Serializable, serialization
+	// failures (40001) are retried like other retryable errors.
+	//
+	// Deprecated: use RunInTransactionWithOptions, for the reasons given on ExecuteInTransaction.
+	ExecuteInTransactionWithOptions(
+		ctx context.Context,
+		tsName string,
+		txOptions TxOptions,
+		fn func(tx pgxdriver.QueryExecuter) error,
+	) error
+
+	// RunInTransaction works like ExecuteInTransaction, passing fn a context carrying the
+	// transaction. Repositories obtain it with Executor, and nested Manager calls made with
+	// this context take part in it.
+	RunInTransaction(
+		ctx context.Context,
+		tsName string,
+		fn func(ctx context.Context) error,
+	) error
+
+	// RunInTransactionWithOptions works like RunInTransaction with the given transaction options.
+	RunInTransactionWithOptions(
+		ctx context.Context,
+		tsName string,
+		txOptions TxOptions,
+		fn func(ctx context.Context) error,
+	) error
 }
 
 // manager is the internal implementation of the Manager interface.
@@ -45,6 +88,10 @@ type manager struct {
 	maxAttempts    int
 	baseRetryDelay time.Duration
 	maxRetryDelay  time.Duration
+	txOptions      TxOptions
+
+	// begin starts a transaction; nil means the pool's BeginTx.
+	begin func(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
 
 // NewManager creates a new transaction manager configured with the given PostgreSQL client and logger.
@@ -74,25 +121,122 @@ func NewManager(
 	return tm, nil
 }
 
-// ExecuteInTransaction executes the provided function within a retriable PostgreSQL transaction.
+// ExecuteInTransaction executes the provided function within a retriable PostgreSQL transaction
+// started with the default transaction options.
 func (tm *manager) ExecuteInTransaction(
 	ctx context.Context,
 	tsName string,
 	fn func(tx pgxdriver.QueryExecuter) error,
+) error {
+	return tm.run(ctx, tsName, tm.txOptions, withExecuter(fn))
+}
+
+// ExecuteInTransactionWithOptions executes the provided function within a retriable PostgreSQL
+// transaction started with the given options.
+func (tm *manager) ExecuteInTransactionWithOptions(
+	ctx context.Context,
+	tsName string,
+	txOptions TxOptions,
+	fn func(tx pgxdriver.QueryExecuter) error,
+) error {
+	const op = "dbpg.pgx-driver.transaction.ExecuteInTransactionWithOptions"
+
+	if err := txOptions.validate(); err != nil {
+		return fmt.Errorf("%s: %s: %w", op, tsName, err)
+	}
+	return tm.run(ctx, tsName, txOptions, withExecuter(fn))
+}
+
+// RunInTransaction executes the provided function within a retriable PostgreSQL transaction
+// started with the default transaction options, passing the transaction in the context.
+func (tm *manager) RunInTransaction(
+	ctx context.Context,
+	tsName string,
+	fn func(ctx context.Context) error,
+) error {
+	return tm.run(ctx, tsName, tm.txOptions, withContext(fn))
+}
+
+// RunInTransactionWithOptions executes the provided function within a retriable PostgreSQL
+// transaction started with the given options, passing the transaction in the context.
+func (tm *manager) RunInTransactionWithOptions(
+	ctx context.Context,
+	tsName string,
+	txOptions TxOptions,
+	fn func(ctx context.Context) error,
+) error {
+	const op = "dbpg.pgx-driver.transaction.RunInTransactionWithOptions"
+
+	if err := txOptions.validate(); err != nil {
+		return fmt.Errorf("%s: %s: %w", op, tsName, err)
+	}
+	return tm.run(ctx, tsName, txOptions, withContext(fn))
+}
+
+// txFunc is a transaction function receiving both the context carrying the transaction
+// and its executer.
+type txFunc func(ctx context.Context, tx pgxdriver.QueryExecuter) error
+
+// withExecuter adapts an ExecuteInTransaction function. The context carrying the transaction
+// is not passed on, so calls nested in fn only join a transaction of the caller's context.
+func withExecuter(fn func(tx pgxdriver.QueryExecuter) error) txFunc {
+	return func(_ context.Context, tx pgxdriver.QueryExecuter) error {
+		return fn(tx)
+	}
+}
+
+// withContext adapts a RunInTransaction function.
+func withContext(fn func(ctx context.Context) error) txFunc {
+	return func(ctx context.Context, _ pgxdriver.QueryExecuter) error {
+		return fn(ctx)
+	}
+}
+
+// run dispatches the call according to the propagation mode and the transaction
+// active in the context.
+func (tm *manager) run(ctx context.Context, tsName string, txOptions TxOptions, fn txFunc) error {
+	if outer, ok := txFromContext(ctx, tm.pool); ok {
+		switch txOptions.Propagation {
+		case Required:
+			if err := fn(ctx, &pgxdriver.TxQueryExecuter{Tx: outer.tx}); err != nil {
+				return HandleError(tsName, "execute", err)
+			}
+			return nil
+		case Nested:
+			return tm.doSavepoint(ctx, tsName, outer, fn)
+		case RequiresNew:
+			// An independent transaction is started below.
+		}
+	}
+
+	if txOptions.IsoLevel == "" {
+		txOptions.IsoLevel = tm.txOptions.IsoLevel
+	}
+	return tm.execute(ctx, tsName, txOptions.pgxOptions(_defaultIsoLevel), fn)
+}
+
+// execute runs the function in transactions started with txOptions, retrying on retryable errors.
+func (tm *manager) execute(
+	ctx context.Context,
+	tsName string,
+	txOptions pgx.TxOptions,
+	fn txFunc,
 ) error {
 	const op = "dbpg.pgx-driver.transaction.ExecuteInTransaction"
 	var lastErr error
 	currentBackoff := tm.baseRetryDelay
 
 	for attempt := 1; attempt <= tm.maxAttempts; attempt++ {
-		err := tm.doTransaction(ctx, tsName, fn)
+		hooks, err := tm.doTransaction(ctx, tsName, txOptions, fn)
 		if err == nil {
+			tm.runHooks(ctx, tsName, hooks.committed())
 			return nil
 		}
 
 		lastErr = err
 
 		if !isRetryableError(err) || attempt == tm.maxAttempts {
+			tm.runHooks(ctx, tsName, hooks.rolledBack())
 			return err
 		}
 		//nolint:gosec
@@ -113,6 +257,7 @@ func (tm *manager) ExecuteInTransaction(
 		case <-time.After(jitter):
 			currentBackoff = min(currentBackoff*_backoffMultiplier, tm.maxRetryDelay)
 		case <-ctx.Done():
+			tm.runHooks(ctx, tsName, hooks.rolledBack())
 			return ctx.Err()
 		}
 	}
@@ -121,18 +266,57 @@ func (tm *manager) ExecuteInTransaction(
 
 // doTransaction executes a single transaction attempt: begins, runs the user function, and commits.
 // On error, the transaction is rolled back automatically.
-func (tm *manager) doTransaction(ctx context.Context, tsName string, fn func(tx pgxdriver.QueryExecuter) error) error {
-	tx, err := tm.pool.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+// Returns the hooks registered during the attempt.
+func (tm *manager) doTransaction(
+	ctx context.Context,
+	tsName string,
+	txOptions pgx.TxOptions,
+	fn txFunc,
+) (*txHooks, error) {
+	hooks := &txHooks{}
+
+	tx, err := tm.beginTx(ctx, txOptions)
 	if err != nil {
-		return err
+		return hooks, err
 	}
 	defer tm.safelyRollback(ctx, tx, tsName)
 
-	if err := fn(&pgxdriver.TxQueryExecuter{Tx: tx}); err != nil {
+	if err := fn(withTx(ctx, tm.pool, tx, hooks), &pgxdriver.TxQueryExecuter{Tx: tx}); err != nil {
+		return hooks, HandleError(tsName, "execute", err)
+	}
+
+	return hooks, tx.Commit(ctx)
+}
+
+// beginTx starts a transaction on the pool.
+func (tm *manager) beginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if tm.begin != nil {
+		return tm.begin(ctx, txOptions)
+	}
+	return tm.pool.Pool.BeginTx(ctx, txOptions)
+}
+
+// doSavepoint runs the function within a savepoint of the outer transaction. On error,
+// only the changes made since the savepoint are rolled back.
+func (tm *manager) doSavepoint(ctx context.Context, tsName string, outer *txState, fn txFunc) error {
+	sp, err := outer.tx.Begin(ctx)
+	if err != nil {
+		return HandleError(tsName, "savepoint", err)
+	}
+	defer tm.safelyRollback(ctx, sp, tsName)
+
+	hooks := &txHooks{}
+	if err := fn(withTx(ctx, tm.pool, sp, hooks), &pgxdriver.TxQueryExecuter{Tx: sp}); err != nil {
+		outer.hooks.rollback(hooks)
 		return HandleError(tsName, "execute", err)
 	}
 
-	return tx.Commit(ctx)
+	if err := sp.Commit(ctx); err != nil {
+		outer.hooks.rollback(hooks)
+		return HandleError(tsName, "release savepoint", err)
+	}
+	outer.hooks.release(hooks)
+	return nil
 }
 
 // safelyRollback attempts to roll back the transaction and logs only unexpected errors.
@@ -152,12 +336,8 @@ func (tm *manager) safelyRollback(ctx context.Context, tx pgx.Tx, tsName string)
 // isRetryableError determines whether a PostgreSQL error is transient and safe to retry.
 // It includes serialization failures (40001), deadlocks (40P01), and various connection errors.
 func isRetryableError(err error) bool {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		switch pgErr.Code {
-		case "40P01", "40001", "08000", "08003", "08006", "08001", "08004", "08007", "08P01":
-			return true
-		}
+	if pgerr.IsRetryable(err) {
+		return true
 	}
 
 	if errors.Is(err, context.DeadlineExceeded) ||
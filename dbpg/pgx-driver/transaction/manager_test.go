@@ -0,0 +1,198 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg/pgerr"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+func TestNewManager_InvalidTxOptions(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	_, err = transaction.NewManager(nil, log,
+		transaction.DefaultTxOptions(transaction.TxOptions{IsoLevel: "snapshot"}))
+	require.ErrorIs(t, err, transaction.ErrInvalidIsoLevel)
+
+	tm, err := transaction.NewManager(nil, log,
+		transaction.DefaultTxOptions(transaction.TxOptions{IsoLevel: pgx.Serializable, ReadOnly: true}))
+	require.NoError(t, err)
+
+	err = tm.ExecuteInTransactionWithOptions(context.Background(), "test",
+		transaction.TxOptions{IsoLevel: "snapshot"},
+		func(pgxdriver.QueryExecuter) error { return nil })
+	require.ErrorIs(t, err, transaction.ErrInvalidIsoLevel)
+}
+
+func TestExecutor_OutsideTransaction(t *testing.T) {
+	pg := &pgxdriver.Postgres{}
+
+	assert.Same(t, pg, transaction.Executor(context.Background(), pg))
+	assert.False(t, transaction.InTransaction(context.Background(), pg))
+}
+
+func TestNewManager_InvalidPropagation(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	_, err = transaction.NewManager(nil, log,
+		transaction.DefaultTxOptions(transaction.TxOptions{Propagation: transaction.Nested + 1}))
+	require.ErrorIs(t, err, transaction.ErrInvalidPropagation)
+}
+
+func TestHooks_OutsideTransaction(t *testing.T) {
+	hook := func(context.Context) error { return nil }
+
+	require.ErrorIs(t, transaction.AfterCommit(context.Background(), hook), transaction.ErrNoTransaction)
+	require.ErrorIs(t, transaction.AfterRollback(context.Background(), hook), transaction.ErrNoTransaction)
+}
+
+func TestHandleError_PgxErrors(t *testing.T) {
+	err := transaction.HandleError("create_user", "execute", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+	require.ErrorIs(t, err, transaction.ErrConflictingData)
+	require.ErrorIs(t, err, pgerr.ErrUniqueViolation)
+
+	var pgErr *pgerr.Error
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "users_email_key", pgErr.Constraint)
+
+	err = transaction.HandleError("transfer", "execute", &pgconn.PgError{Code: "40001"})
+	require.ErrorIs(t, err, pgerr.ErrSerializationFailure)
+	assert.True(t, pgerr.IsRetryable(err))
+}
+
+// dispatchManager returns a manager on pg starting fake transactions and a context
+// carrying the outer fake transaction "outer".
+func dispatchManager(t *testing.T, pg *pgxdriver.Postgres) (transaction.Manager, context.Context, *txLog) {
+	t.Helper()
+
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	txs := &txLog{}
+	tm, err := transaction.NewManagerWithBegin(pg, log, txs.begin(), transaction.MaxAttempts(1))
+	require.NoError(t, err)
+
+	ctx := transaction.WithTx(context.Background(), pg, &fakeTx{name: "outer", log: txs})
+	return tm, ctx, txs
+}
+
+func TestRun_RequiredJoinsContextTransaction(t *testing.T) {
+	pg := &pgxdriver.Postgres{}
+	tm, ctx, txs := dispatchManager(t, pg)
+
+	err := tm.RunInTransaction(ctx, "join", func(ctx context.Context) error {
+		_, err := transaction.Executor(ctx, pg).Exec(ctx, "INSERT 1")
+		return err
+	})
+	require.NoError(t, err)
+
+	err = tm.ExecuteInTransaction(ctx, "join", func(tx pgxdriver.QueryExecuter) error {
+		_, err := tx.Exec(ctx, "INSERT 2")
+		return err
+	})
+	require.NoError(t, err)
+
+	errFail := errors.New("fail")
+	err = tm.RunInTransaction(ctx, "join", func(context.Context) error { return errFail })
+	require.ErrorIs(t, err, errFail)
+
+	// A joined call neither commits nor rolls back the outer transaction.
+	assert.Equal(t, []string{"outer: INSERT 1", "outer: INSERT 2"}, txs.Events())
+}
+
+func TestRun_NestedUsesSavepoint(t *testing.T) {
+	pg := &pgxdriver.Postgres{}
+	tm, ctx, txs := dispatchManager(t, pg)
+	nested := transaction.TxOptions{Propagation: transaction.Nested}
+
+	err := tm.RunInTransactionWithOptions(ctx, "nested", nested, func(ctx context.Context) error {
+		_, err := transaction.Executor(ctx, pg).Exec(ctx, "INSERT 1")
+		return err
+	})
+	require.NoError(t, err)
+
+	errFail := errors.New("fail")
+	err = tm.RunInTransactionWithOptions(ctx, "nested", nested, func(ctx context.Context) error {
+		_, err := transaction.Executor(ctx, pg).Exec(ctx, "INSERT 2")
+		require.NoError(t, err)
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+
+	assert.Equal(t, []string{
+		"savepoint outer/sp", "outer/sp: INSERT 1", "commit outer/sp",
+		"savepoint outer/sp", "outer/sp: INSERT 2", "rollback outer/sp",
+	}, txs.Events())
+}
+
+func TestRun_RequiresNewStartsIndependentTransaction(t *testing.T) {
+	pg := &pgxdriver.Postgres{}
+	tm, ctx, txs := dispatchManager(t, pg)
+
+	err := tm.RunInTransactionWithOptions(ctx, "new", transaction.TxOptions{Propagation: transaction.RequiresNew},
+		func(ctx context.Context) error {
+			_, err := transaction.Executor(ctx, pg).Exec(ctx, "INSERT 1")
+			return err
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin tx1", "tx1: INSERT 1", "commit tx1"}, txs.Events())
+}
+
+func TestRun_OtherPoolTransactionIgnored(t *testing.T) {
+	tm, _, txs := dispatchManager(t, &pgxdriver.Postgres{})
+	other := &pgxdriver.Postgres{}
+	ctx := transaction.WithTx(context.Background(), other, &fakeTx{name: "other", log: txs})
+
+	err := tm.RunInTransaction(ctx, "other", func(ctx context.Context) error {
+		assert.False(t, transaction.InTransaction(ctx, other))
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin tx1", "commit tx1"}, txs.Events())
+}
+
+func TestExecuteInTransaction_NestedCallOpensIndependentTransaction(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	txs := &txLog{}
+	tm, err := transaction.NewManagerWithBegin(&pgxdriver.Postgres{}, log, txs.begin())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = tm.ExecuteInTransaction(ctx, "outer", func(pgxdriver.QueryExecuter) error {
+		// fn does not receive a context carrying the transaction.
+		return tm.ExecuteInTransaction(ctx, "inner", func(pgxdriver.QueryExecuter) error { return nil })
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin tx1", "begin tx2", "commit tx2", "commit tx1"}, txs.Events())
+}
+
+func TestRunInTransaction_NestedCallJoins(t *testing.T) {
+	log, err := logger.InitLogger(logger.SlogEngine, "test", "test")
+	require.NoError(t, err)
+
+	txs := &txLog{}
+	tm, err := transaction.NewManagerWithBegin(&pgxdriver.Postgres{}, log, txs.begin())
+	require.NoError(t, err)
+
+	err = tm.RunInTransaction(context.Background(), "outer", func(ctx context.Context) error {
+		return tm.RunInTransaction(ctx, "inner", func(context.Context) error { return nil })
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin tx1", "commit tx1"}, txs.Events())
+}
@@ -3,6 +3,8 @@ package transaction
 import (
 	"errors"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 var (
@@ -14,8 +16,43 @@ var (
 	ErrInvalidMaxRetryDelay = errors.New("invalid max retry delay: must be > 0")
 	// ErrBaseExceedsMaxDelay is returned when BaseRetryDelay > MaxRetryDelay.
 	ErrBaseExceedsMaxDelay = errors.New("baseRetryDelay cannot exceed maxRetryDelay")
+	// ErrInvalidIsoLevel is returned when TxOptions.IsoLevel is not a PostgreSQL isolation level.
+	ErrInvalidIsoLevel = errors.New("invalid isolation level")
+	// ErrInvalidPropagation is returned when TxOptions.Propagation is not a known propagation mode.
+	ErrInvalidPropagation = errors.New("invalid propagation")
+)
+
+// Propagation defines how a Manager call behaves when its context already carries a transaction.
+type Propagation int
+
+const (
+	// Required joins the transaction of the context, or starts a new one if there is none.
+	Required Propagation = iota
+	// RequiresNew always starts an independent transaction on another connection.
+	// The outer transaction is neither affected by its outcome nor visible to it.
+	RequiresNew
+	// Nested runs within a SAVEPOINT of the transaction of the context, or starts a new
+	// transaction if there is none. On error, only the changes made since the savepoint
+	// are rolled back and the outer transaction may continue.
+	Nested
 )
 
+// TxOptions configures the transactions started by the manager.
+type TxOptions struct {
+	// IsoLevel is the isolation level: pgx.Serializable, pgx.RepeatableRead, pgx.ReadCommitted
+	// or pgx.ReadUncommitted. Empty means the manager default (pgx.ReadCommitted unless set
+	// with DefaultTxOptions).
+	IsoLevel pgx.TxIsoLevel
+	// ReadOnly starts a READ ONLY transaction.
+	ReadOnly bool
+	// Deferrable starts a DEFERRABLE transaction. It only has an effect on SERIALIZABLE
+	// READ ONLY transactions, which then wait for a snapshot that cannot cause serialization failures.
+	Deferrable bool
+	// Propagation defines how the call behaves when its context already carries a transaction
+	// (Required by default). Joined calls and savepoints ignore the isolation level and access modes.
+	Propagation Propagation
+}
+
 // Option represents a functional configuration option for the transaction manager.
 type Option func(*manager)
 
@@ -44,6 +81,14 @@ func MaxRetryDelay(delay time.Duration) Option {
 	}
 }
 
+// DefaultTxOptions sets the options of ExecuteInTransaction and RunInTransaction calls,
+// and the isolation level used by the ...WithOptions methods when none is given.
+func DefaultTxOptions(opts TxOptions) Option {
+	return func(m *manager) {
+		m.txOptions = opts
+	}
+}
+
 // validate checks that all transaction manager configuration parameters are valid.
 // It returns an error if any parameter violates its constraints.
 func (m *manager) validate() error {
@@ -62,5 +107,36 @@ func (m *manager) validate() error {
 	if m.baseRetryDelay > m.maxRetryDelay {
 		return ErrBaseExceedsMaxDelay
 	}
+
+	return m.txOptions.validate()
+}
+
+// validate checks that the isolation level is empty or known to PostgreSQL
+// and that the propagation mode is known.
+func (o TxOptions) validate() error {
+	switch o.IsoLevel {
+	case "", pgx.Serializable, pgx.RepeatableRead, pgx.ReadCommitted, pgx.ReadUncommitted:
+	default:
+		return ErrInvalidIsoLevel
+	}
+
+	if o.Propagation < Required || o.Propagation > Nested {
+		return ErrInvalidPropagation
+	}
 	return nil
 }
+
+// pgxOptions converts the options to pgx.TxOptions, using defaultLevel if IsoLevel is empty.
+func (o TxOptions) pgxOptions(defaultLevel pgx.TxIsoLevel) pgx.TxOptions {
+	opts := pgx.TxOptions{IsoLevel: o.IsoLevel}
+	if opts.IsoLevel == "" {
+		opts.IsoLevel = defaultLevel
+	}
+	if o.ReadOnly {
+		opts.AccessMode = pgx.ReadOnly
+	}
+	if o.Deferrable {
+		opts.DeferrableMode = pgx.Deferrable
+	}
+	return opts
+}
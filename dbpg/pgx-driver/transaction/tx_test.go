@@ -0,0 +1,79 @@
+package transaction_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// txLog records the statements and transaction events of fake transactions in order.
+type txLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *txLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, event)
+}
+
+func (l *txLog) Events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.events...)
+}
+
+// begin returns a begin function of a Manager starting fake transactions named tx1, tx2, ...
+func (l *txLog) begin() func(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	var n int
+	return func(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+		n++
+		tx := &fakeTx{name: "tx" + strconv.Itoa(n), log: l}
+		l.add("begin " + tx.name)
+		return tx, nil
+	}
+}
+
+// fakeTx is a pgx.Tx recording its statements, savepoints and outcome to a txLog.
+type fakeTx struct {
+	pgx.Tx
+
+	name   string
+	log    *txLog
+	closed bool
+}
+
+func (t *fakeTx) Begin(context.Context) (pgx.Tx, error) {
+	sp := &fakeTx{name: t.name + "/sp", log: t.log}
+	t.log.add("savepoint " + sp.name)
+	return sp, nil
+}
+
+func (t *fakeTx) Commit(context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	t.log.add("commit " + t.name)
+	return nil
+}
+
+func (t *fakeTx) Rollback(context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	t.log.add("rollback " + t.name)
+	return nil
+}
+
+func (t *fakeTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	t.log.add(t.name + ": " + sql)
+	return pgconn.CommandTag{}, nil
+}
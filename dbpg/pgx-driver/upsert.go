@@ -0,0 +1,216 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrNoColumns is returned when BulkUpsert is called without columns.
+	ErrNoColumns = errors.New("no columns")
+	// ErrNoConflictColumns is returned when BulkUpsert is called without conflict columns
+	// or with a conflict column missing from the columns.
+	ErrNoConflictColumns = errors.New("invalid conflict columns: must be a non-empty subset of columns")
+	// ErrInvalidUpdateColumns is returned when an UpdateColumns column is missing from the columns.
+	ErrInvalidUpdateColumns = errors.New("invalid update columns: must be a subset of columns")
+	// ErrTxNotSupported is returned when the QueryExecuter cannot start a transaction.
+	ErrTxNotSupported = errors.New("query executer does not support transactions")
+)
+
+// upsertTableSeq makes staging table names unique within a session.
+var upsertTableSeq atomic.Uint64
+
+// UpsertResult reports the outcome of BulkUpsert.
+type UpsertResult struct {
+	// Inserted is the number of rows inserted as new.
+	Inserted int64
+	// Updated is the number of existing rows updated on conflict.
+	Updated int64
+	// Skipped is the number of conflicting rows left unchanged (DoNothing mode).
+	Skipped int64
+}
+
+// UpsertOption represents a functional configuration option for BulkUpsert.
+type UpsertOption func(*upsertConfig)
+
+// upsertConfig holds BulkUpsert settings.
+type upsertConfig struct {
+	doNothing     bool
+	updateColumns []string
+}
+
+// DoNothing leaves conflicting rows unchanged (ON CONFLICT ... DO NOTHING)
+// and reports them as skipped.
+func DoNothing() UpsertOption {
+	return func(c *upsertConfig) {
+		c.doNothing = true
+	}
+}
+
+// UpdateColumns sets the columns overwritten on conflict. By default all columns
+// except the conflict columns are updated.
+func UpdateColumns(columns ...string) UpsertOption {
+	return func(c *upsertConfig) {
+		c.updateColumns = columns
+	}
+}
+
+// BulkUpsert loads rows with COPY FROM into a temporary staging table and then merges them into
+// the target table with INSERT ... ON CONFLICT (conflictColumns) DO UPDATE, or DO NOTHING with
+// the DoNothing option. Unlike BulkInsert, duplicate keys do not abort the load.
+// The table name is a string, []string or pgx.Identifier; conflictColumns must match a unique
+// index or constraint of the table. The input must not contain duplicate conflict keys.
+//
+// All steps run in one transaction: on a *Postgres a new transaction is started, on a
+// *TxQueryExecuter (e.g. inside transaction.Manager) a savepoint is used, so the upsert
+// is atomic and composes with the surrounding transaction.
+func BulkUpsert(
+	ctx context.Context,
+	qe QueryExecuter,
+	tableName any,
+	columns []string,
+	conflictColumns []string,
+	data [][]any,
+	opts ...UpsertOption,
+) (UpsertResult, error) {
+	const op = "dbpg.pgx-driver.BulkUpsert"
+
+	var cfg upsertConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.validate(columns, conflictColumns); err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: validation: %w", op, err)
+	}
+
+	ident, err := tableIdentifier(tableName)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := beginTx(ctx, qe)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	txe := &TxQueryExecuter{Tx: tx}
+
+	staging := pgx.Identifier{"wbf_upsert_" + strconv.FormatUint(upsertTableSeq.Add(1), 10)}
+	columnList := sanitizeColumns(columns)
+
+	createSQL := fmt.Sprintf("CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		staging.Sanitize(), columnList, ident.Sanitize())
+	if _, err := txe.Exec(ctx, createSQL); err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: create staging table: %w", op, err)
+	}
+
+	total, err := txe.CopyFrom(ctx, staging, columns, pgx.CopyFromRows(data))
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: copy from: %w", op, err)
+	}
+
+	var res UpsertResult
+	if err := txe.QueryRow(ctx, cfg.mergeSQL(ident, staging, columns, conflictColumns)).
+		Scan(&res.Inserted, &res.Updated); err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: merge: %w", op, err)
+	}
+	res.Skipped = total - res.Inserted - res.Updated
+
+	if _, err := txe.Exec(ctx, "DROP TABLE "+staging.Sanitize()); err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: drop staging table: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return UpsertResult{}, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// validate checks the column lists against the options.
+func (c *upsertConfig) validate(columns, conflictColumns []string) error {
+	if len(columns) == 0 {
+		return ErrNoColumns
+	}
+
+	if len(conflictColumns) == 0 {
+		return ErrNoConflictColumns
+	}
+	for _, col := range conflictColumns {
+		if !slices.Contains(columns, col) {
+			return ErrNoConflictColumns
+		}
+	}
+
+	for _, col := range c.updateColumns {
+		if !slices.Contains(columns, col) {
+			return ErrInvalidUpdateColumns
+		}
+	}
+	return nil
+}
+
+// mergeSQL builds the INSERT ... ON CONFLICT statement moving rows from the staging table
+// to the target table. It returns the numbers of inserted and updated rows: a row version
+// created by an insert has xmax = 0.
+func (c *upsertConfig) mergeSQL(table, staging pgx.Identifier, columns, conflictColumns []string) string {
+	update := c.updateColumns
+	if update == nil {
+		update = slices.DeleteFunc(slices.Clone(columns), func(col string) bool {
+			return slices.Contains(conflictColumns, col)
+		})
+	}
+
+	action := "DO NOTHING"
+	if !c.doNothing && len(update) > 0 {
+		assignments := make([]string, len(update))
+		for i, col := range update {
+			name := pgx.Identifier{col}.Sanitize()
+			assignments[i] = name + " = EXCLUDED." + name
+		}
+		action = "DO UPDATE SET " + strings.Join(assignments, ", ")
+	}
+
+	columnList := sanitizeColumns(columns)
+	return fmt.Sprintf(
+		"WITH merged AS (INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) %s RETURNING xmax = 0 AS inserted) "+
+			"SELECT count(*) FILTER (WHERE inserted), count(*) FILTER (WHERE NOT inserted) FROM merged",
+		table.Sanitize(), columnList, columnList, staging.Sanitize(),
+		sanitizeColumns(conflictColumns), action,
+	)
+}
+
+// sanitizeColumns quotes column names and joins them into a comma-separated list.
+func sanitizeColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = pgx.Identifier{col}.Sanitize()
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// beginTx starts a transaction on the executer's connection pool, or a savepoint
+// if the executer already runs in a transaction.
+func beginTx(ctx context.Context, qe QueryExecuter) (pgx.Tx, error) {
+	switch e := qe.(type) {
+	case *Postgres:
+		return e.Pool.Begin(ctx)
+	case *TxQueryExecuter:
+		return e.Tx.Begin(ctx)
+	case interface {
+		Begin(ctx context.Context) (pgx.Tx, error)
+	}:
+		return e.Begin(ctx)
+	default:
+		return nil, ErrTxNotSupported
+	}
+}
@@ -0,0 +1,120 @@
+package pgxdriver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// upsertTx records the statements of a BulkUpsert transaction.
+type upsertTx struct {
+	pgx.Tx
+
+	statements []string
+	copied     int64
+	committed  bool
+}
+
+func (t *upsertTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	t.statements = append(t.statements, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (t *upsertTx) CopyFrom(_ context.Context, _ pgx.Identifier, _ []string, src pgx.CopyFromSource) (int64, error) {
+	for src.Next() {
+		t.copied++
+	}
+	return t.copied, src.Err()
+}
+
+func (t *upsertTx) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	t.statements = append(t.statements, sql)
+	return countsRow{inserted: 1, updated: 1}
+}
+
+func (t *upsertTx) Commit(context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *upsertTx) Rollback(context.Context) error {
+	return nil
+}
+
+type countsRow struct {
+	inserted, updated int64
+}
+
+func (r countsRow) Scan(dest ...any) error {
+	*dest[0].(*int64), *dest[1].(*int64) = r.inserted, r.updated
+	return nil
+}
+
+// beginExecuter starts upsertTx transactions.
+type beginExecuter struct {
+	pgxdriver.QueryExecuter
+
+	tx *upsertTx
+}
+
+func (b *beginExecuter) Begin(context.Context) (pgx.Tx, error) {
+	return b.tx, nil
+}
+
+func TestBulkUpsert_DoUpdate(t *testing.T) {
+	qe := &beginExecuter{tx: &upsertTx{}}
+
+	res, err := pgxdriver.BulkUpsert(context.Background(), qe, "users",
+		[]string{"id", "name", "email"}, []string{"id"},
+		[][]any{{1, "alice", "a@x"}, {2, "bob", "b@x"}, {3, "carol", "c@x"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, pgxdriver.UpsertResult{Inserted: 1, Updated: 1, Skipped: 1}, res)
+	assert.True(t, qe.tx.committed)
+	require.Len(t, qe.tx.statements, 3)
+	assert.Contains(t, qe.tx.statements[0], `AS SELECT "id", "name", "email" FROM "users" WITH NO DATA`)
+	assert.Contains(t, qe.tx.statements[1],
+		`INSERT INTO "users" ("id", "name", "email") SELECT "id", "name", "email" FROM "wbf_upsert_`)
+	assert.Contains(t, qe.tx.statements[1],
+		`ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "email" = EXCLUDED."email"`)
+	assert.Contains(t, qe.tx.statements[2], `DROP TABLE "wbf_upsert_`)
+}
+
+func TestBulkUpsert_Options(t *testing.T) {
+	qe := &beginExecuter{tx: &upsertTx{}}
+
+	_, err := pgxdriver.BulkUpsert(context.Background(), qe, "users",
+		[]string{"id", "name"}, []string{"id"}, [][]any{{1, "alice"}}, pgxdriver.DoNothing())
+	require.NoError(t, err)
+	assert.Contains(t, qe.tx.statements[1], `ON CONFLICT ("id") DO NOTHING`)
+
+	qe = &beginExecuter{tx: &upsertTx{}}
+	_, err = pgxdriver.BulkUpsert(context.Background(), qe, "users",
+		[]string{"id", "name", "email"}, []string{"id"}, [][]any{{1, "alice", "a@x"}},
+		pgxdriver.UpdateColumns("email"))
+	require.NoError(t, err)
+	assert.Contains(t, qe.tx.statements[1], `DO UPDATE SET "email" = EXCLUDED."email" RETURNING`)
+}
+
+func TestBulkUpsert_Validation(t *testing.T) {
+	ctx := context.Background()
+	qe := &beginExecuter{tx: &upsertTx{}}
+
+	_, err := pgxdriver.BulkUpsert(ctx, qe, "users", nil, []string{"id"}, nil)
+	require.ErrorIs(t, err, pgxdriver.ErrNoColumns)
+
+	_, err = pgxdriver.BulkUpsert(ctx, qe, "users", []string{"id"}, []string{"name"}, nil)
+	require.ErrorIs(t, err, pgxdriver.ErrNoConflictColumns)
+
+	_, err = pgxdriver.BulkUpsert(ctx, qe, "users", []string{"id"}, []string{"id"}, nil,
+		pgxdriver.UpdateColumns("name"))
+	require.ErrorIs(t, err, pgxdriver.ErrInvalidUpdateColumns)
+
+	_, err = pgxdriver.BulkUpsert(ctx, &copyRecorder{}, "users", []string{"id"}, []string{"id"}, nil)
+	require.ErrorIs(t, err, pgxdriver.ErrTxNotSupported)
+}
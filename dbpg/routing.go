@@ -0,0 +1,282 @@
+package dbpg
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// writeTrackerKey is the context key for the read-your-writes tracker.
+type writeTrackerKey struct{}
+
+// routeKey is the context key for an explicit read route.
+type routeKey struct{}
+
+// route is an explicit choice of the node for reads.
+type route int
+
+const (
+	routeDefault route = iota
+	routeMaster
+	routeReplica
+)
+
+// WithMaster returns a context whose reads (QueryContext, QueryRowContext and their
+// retry variants) are sent to the master, e.g. for consistency-critical reads.
+func WithMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routeMaster)
+}
+
+// WithReplica returns a context whose reads are sent to a slave even within
+// the read-your-writes window. Write statements still go to the master,
+// and so do reads when no healthy slave is available.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, routeReplica)
+}
+
+// routeFrom returns the explicit read route stored in the context.
+func routeFrom(ctx context.Context) route {
+	r, _ := ctx.Value(routeKey{}).(route)
+	return r
+}
+
+// writeKeywords are leading statement keywords that modify data or schema.
+// DO runs an anonymous code block, which may modify anything.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true,
+	"GRANT": true, "REVOKE": true, "COPY": true, "CALL": true,
+	"LOCK": true, "REFRESH": true, "VACUUM": true, "REINDEX": true,
+	"DO": true,
+}
+
+// explainOptions are the words that may appear between EXPLAIN and the explained statement.
+var explainOptions = map[string]bool{
+	"ANALYZE": true, "ANALYSE": true, "VERBOSE": true, "COSTS": true, "SETTINGS": true,
+	"GENERIC_PLAN": true, "BUFFERS": true, "SERIALIZE": true, "WAL": true, "TIMING": true,
+	"SUMMARY": true, "MEMORY": true, "FORMAT": true, "TEXT": true, "XML": true, "JSON": true,
+	"YAML": true, "NONE": true, "BINARY": true, "TRUE": true, "FALSE": true, "ON": true,
+	"OFF": true, "1": true, "0": true,
+}
+
+// isWriteStatement reports whether the query must run on the master: it contains
+// a data-modifying statement (including data-modifying CTEs, SELECT ... INTO, DO blocks
+// and EXPLAIN ANALYZE of a write) or SELECT with a locking clause such as FOR UPDATE
+// or FOR SHARE. A query of several statements separated by semicolons is a write
+// if any of them is.
+func isWriteStatement(query string) bool {
+	for _, words := range sqlStatements(query) {
+		if isWrite(words) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWrite reports whether the words of a single statement make it a write.
+func isWrite(words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	if writeKeywords[words[0]] {
+		return true
+	}
+	if words[0] == "EXPLAIN" {
+		// EXPLAIN without ANALYZE only plans the statement, which a replica can do.
+		inner, analyze := explainedStatement(words[1:])
+		return analyze && isWrite(inner)
+	}
+
+	for i, word := range words {
+		switch word {
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			// Inside WITH, a data-modifying statement makes the whole query a write.
+			if words[0] == "WITH" {
+				return true
+			}
+		case "INTO":
+			// SELECT ... INTO creates a table.
+			return true
+		case "FOR":
+			if i+1 < len(words) && isLockStrength(words[i+1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// explainedStatement skips the options of EXPLAIN and returns the explained statement
+// and whether the statement is executed (ANALYZE not followed by FALSE, OFF or 0).
+func explainedStatement(words []string) ([]string, bool) {
+	analyze := false
+	i := 0
+	for ; i < len(words) && explainOptions[words[i]]; i++ {
+		if words[i] != "ANALYZE" && words[i] != "ANALYSE" {
+			continue
+		}
+		analyze = true
+		if i+1 < len(words) {
+			switch words[i+1] {
+			case "FALSE", "OFF", "0":
+				analyze = false
+				i++
+			}
+		}
+	}
+	return words[i:], analyze
+}
+
+// isLockStrength reports whether words start with a row-locking strength
+// following FOR: UPDATE, NO KEY UPDATE, SHARE or KEY SHARE.
+func isLockStrength(words []string) bool {
+	switch words[0] {
+	case "UPDATE", "SHARE":
+		return true
+	case "NO", "KEY":
+		return len(words) > 1 && (words[1] == "KEY" || words[1] == "SHARE")
+	}
+	return false
+}
+
+// sqlStatements splits a query into statements separated by semicolons and returns
+// the upper-cased keywords and identifiers of each one, skipping string literals,
+// quoted identifiers, dollar-quoted strings and comments.
+func sqlStatements(query string) [][]string {
+	var (
+		statements [][]string
+		words      []string
+		word       strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"':
+			flush()
+			i = skipQuoted(runes, i, r)
+		case r == '$' && word.Len() == 0:
+			// $tag$ starts a dollar-quoted string, $1 is a parameter.
+			if end, ok := skipDollarQuoted(runes, i); ok {
+				i = end
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			flush()
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			flush()
+			i = skipBlockComment(runes, i)
+		case r == ';':
+			flush()
+			if len(words) > 0 {
+				statements = append(statements, words)
+				words = nil
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || (r == '$' && word.Len() > 0):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	if len(words) > 0 {
+		statements = append(statements, words)
+	}
+
+	return statements
+}
+
+// skipQuoted returns the index of the closing quote of a literal starting at i.
+// Doubled quotes inside the literal are treated as escaped quotes.
+func skipQuoted(runes []rune, i int, quote rune) int {
+	for i++; i < len(runes); i++ {
+		if runes[i] != quote {
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == quote {
+			i++
+			continue
+		}
+		return i
+	}
+	return i
+}
+
+// skipDollarQuoted returns the index of the last character of a dollar-quoted string
+// ($$...$$ or $tag$...$tag$) starting at i. It returns false if i does not start one.
+func skipDollarQuoted(runes []rune, i int) (int, bool) {
+	j := i + 1
+	for ; j < len(runes) && runes[j] != '$'; j++ {
+		r := runes[j]
+		if !(unicode.IsLetter(r) || r == '_' || (j > i+1 && unicode.IsDigit(r))) {
+			return i, false
+		}
+	}
+	if j >= len(runes) {
+		return i, false
+	}
+
+	delim := string(runes[i : j+1])
+	body := string(runes[j+1:])
+	end := strings.Index(body, delim)
+	if end < 0 {
+		return len(runes), true
+	}
+	return j + len([]rune(body[:end])) + len([]rune(delim)), true
+}
+
+// skipBlockComment returns the index of the last character of a block comment starting at i.
+func skipBlockComment(runes []rune, i int) int {
+	for i += 2; i+1 < len(runes); i++ {
+		if runes[i] == '*' && runes[i+1] == '/' {
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// writeTracker remembers the time of the last successful write made with a context.
+type writeTracker struct {
+	lastWrite atomic.Int64 // Unix nanoseconds; zero means no writes yet.
+}
+
+// WithReadYourWrites returns a context that tracks writes made through DB.
+// After a successful ExecContext, a write statement run with QueryContext or QueryRowContext
+// (e.g. INSERT ... RETURNING) or a committed WithTx on this context, reads made
+// with the same context are pinned to the master for Options.ReadYourWritesWindow,
+// so they never observe a replica that has not replayed the write yet.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(writeTrackerKey{}).(*writeTracker); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, writeTrackerKey{}, &writeTracker{})
+}
+
+// markWrite records a successful write in the context tracker, if there is one.
+func markWrite(ctx context.Context) {
+	if tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker); ok {
+		tracker.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+// wroteWithin reports whether the context tracker saw a write within the window.
+func wroteWithin(ctx context.Context, window time.Duration) bool {
+	tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker)
+	if !ok {
+		return false
+	}
+
+	last := tracker.lastWrite.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < window
+}
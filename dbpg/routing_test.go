@@ -0,0 +1,55 @@
+package dbpg_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+func TestIsWriteStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM users", false},
+		{"  select id from users where name = $1", false},
+		{"INSERT INTO users (name) VALUES ($1) RETURNING id", true},
+		{"update users set name = 'x'", true},
+		{"-- comment\nDELETE FROM users", true},
+		{"/* DELETE */ SELECT 1", false},
+		{"SELECT 'DELETE FROM users'", false},
+		{`SELECT "update" FROM t`, false},
+		{"WITH moved AS (DELETE FROM a RETURNING *) SELECT * FROM moved", true},
+		{"WITH recent AS (SELECT * FROM a) SELECT * FROM recent", false},
+		{"SELECT * FROM users FOR UPDATE", true},
+		{"SELECT * FROM users FOR NO KEY UPDATE SKIP LOCKED", true},
+		{"SELECT * FROM users FOR KEY SHARE", true},
+		{"SELECT * INTO new_t FROM users", true},
+		{"select id into temp tmp_ids from users", true},
+		{"EXPLAIN SELECT * FROM users", false},
+		{"EXPLAIN DELETE FROM users", false},
+		{"EXPLAIN ANALYZE DELETE FROM users", true},
+		{"EXPLAIN ANALYZE SELECT * FROM users", false},
+		{"EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) UPDATE users SET name = 'x'", true},
+		{"EXPLAIN (ANALYZE false) DELETE FROM users", false},
+		{"EXPLAIN VERBOSE ANALYZE WITH d AS (DELETE FROM a RETURNING *) SELECT * FROM d", true},
+		{"DO $$ BEGIN UPDATE users SET active = false; END $$", true},
+		{"SELECT 1; UPDATE users SET name = 'x'", true},
+		{"SELECT 1; SELECT 2;", false},
+		{"SELECT ';'; SELECT 2", false},
+		{"SELECT $$; DELETE FROM users$$", false},
+		{"SELECT $tag$ it's $$ DELETE $$ $tag$, 1", false},
+		{"SELECT $body$ x $body$; DELETE FROM users", true},
+		{"SELECT a$b FROM t", false},
+		{"CREATE TABLE t (id int)", true},
+		{"", false},
+		{";", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, dbpg.IsWriteStatement(tt.query))
+		})
+	}
+}
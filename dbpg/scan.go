@@ -0,0 +1,31 @@
+package dbpg
+
+import (
+	"database/sql"
+
+	"github.com/wb-go/wbf/dbpg/scan"
+)
+
+// ErrNotFound is returned by ScanOne when the query returned no rows.
+// It replaces sql.ErrNoRows for typed scanning.
+var ErrNotFound = scan.ErrNotFound
+
+// ScanAll scans all rows into a slice of T and closes rows.
+// Struct fields are matched to columns by the `db:"column"` tag or the lower-cased field name;
+// embedded structs are flattened and pointer or sql.Null* fields accept NULL.
+// Non-struct T is scanned from a single-column result.
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	return scan.All[T](rows)
+}
+
+// ScanOne scans the first row into T and closes rows.
+// It returns ErrNotFound if the query returned no rows.
+func ScanOne[T any](rows *sql.Rows) (T, error) {
+	return scan.One[T](rows)
+}
+
+// ScanOptional scans the first row into T and closes rows.
+// It returns nil without an error if the query returned no rows.
+func ScanOptional[T any](rows *sql.Rows) (*T, error) {
+	return scan.Optional[T](rows)
+}
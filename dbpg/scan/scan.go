@@ -0,0 +1,256 @@
+// Package scan maps query result rows to Go values. Struct fields are matched to columns
+// by the `db:"column"` tag or, without a tag, by the lower-cased field name; embedded
+// structs are flattened and pointer fields receive NULL values. Non-struct types
+// (and types implementing sql.Scanner) are scanned directly from a single column.
+// It backs the typed scanning helpers of dbpg and pgxdriver.
+package scan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by One when the result set is empty.
+	ErrNotFound = errors.New("no rows in result set")
+	// ErrUnmappedColumn is returned when a result column has no matching struct field.
+	ErrUnmappedColumn = errors.New("column has no destination field")
+	// ErrColumnCount is returned when a non-struct value is scanned from a result
+	// that does not have exactly one column.
+	ErrColumnCount = errors.New("non-struct destination requires exactly one column")
+)
+
+// Rows is the subset of a driver result set used for scanning.
+// *sql.Rows implements it directly; pgx rows are adapted by pgxdriver.
+type Rows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
+// All scans every row into a slice of T and closes rows.
+func All[T any](rows Rows) ([]T, error) {
+	const op = "dbpg.scan.All"
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	p, err := newPlan[T](rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var result []T
+	for rows.Next() {
+		var v T
+		if err := rows.Scan(p.targets(reflect.ValueOf(&v).Elem())...); err != nil {
+			return nil, fmt.Errorf("%s: scan row %d: %w", op, len(result), err)
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// One scans the first row into T and closes rows.
+// It returns ErrNotFound if the result set is empty.
+func One[T any](rows Rows) (T, error) {
+	const op = "dbpg.scan.One"
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var v T
+	p, err := newPlan[T](rows)
+	if err != nil {
+		return v, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return v, fmt.Errorf("%s: %w", op, err)
+		}
+		return v, ErrNotFound
+	}
+
+	if err := rows.Scan(p.targets(reflect.ValueOf(&v).Elem())...); err != nil {
+		return v, fmt.Errorf("%s: scan row: %w", op, err)
+	}
+
+	return v, nil
+}
+
+// Optional scans the first row into T and closes rows.
+// It returns nil without an error if the result set is empty.
+func Optional[T any](rows Rows) (*T, error) {
+	v, err := One[T](rows)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// plan describes where each result column is stored in a value of the destination type.
+type plan struct {
+	direct bool    // The value itself is the single destination.
+	paths  [][]int // Field index path per column for struct destinations.
+}
+
+// newPlan builds a scan plan for T and the columns of rows.
+func newPlan[T any](rows Rows) (plan, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return plan{}, fmt.Errorf("columns: %w", err)
+	}
+
+	if err := rows.Err(); err != nil {
+		return plan{}, err
+	}
+
+	t := reflect.TypeFor[T]()
+	if !isStructDestination(t) {
+		if len(columns) != 1 {
+			// A failed query may report no columns until its error is read
+			// (e.g. with pgx's simple protocol): return that error instead.
+			if len(columns) == 0 && !rows.Next() {
+				if err := rows.Err(); err != nil {
+					return plan{}, err
+				}
+			}
+			return plan{}, fmt.Errorf("%w: got %d", ErrColumnCount, len(columns))
+		}
+		return plan{direct: true}, nil
+	}
+
+	fields := fieldsOf(t)
+	paths := make([][]int, len(columns))
+	for i, column := range columns {
+		path, ok := fields[strings.ToLower(column)]
+		if !ok {
+			return plan{}, fmt.Errorf("%w: %q", ErrUnmappedColumn, column)
+		}
+		paths[i] = path
+	}
+
+	return plan{paths: paths}, nil
+}
+
+// targets returns scan destinations for the addressable value v,
+// allocating nil embedded struct pointers on the way.
+func (p plan) targets(v reflect.Value) []any {
+	if p.direct {
+		return []any{v.Addr().Interface()}
+	}
+
+	targets := make([]any, len(p.paths))
+	for i, path := range p.paths {
+		targets[i] = fieldByPath(v, path).Addr().Interface()
+	}
+	return targets
+}
+
+// fieldByPath returns the field of v at the index path, allocating nil pointers.
+func fieldByPath(v reflect.Value, path []int) reflect.Value {
+	for _, idx := range path {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// isStructDestination reports whether t is scanned field by field rather than as a single value.
+func isStructDestination(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// fieldCache maps struct types to their column-to-field index.
+var fieldCache sync.Map
+
+// fieldsOf returns the lower-cased column name to field index path mapping of t.
+func fieldsOf(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string][]int) //nolint:forcetypeassert
+	}
+
+	fields := make(map[string][]int)
+	collectFields(t, nil, fields)
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.(map[string][]int) //nolint:forcetypeassert
+}
+
+// collectFields adds the columns of struct t to fields. Direct fields are added before
+// fields of embedded structs, so that shallower fields win on name conflicts.
+func collectFields(t reflect.Type, prefix []int, fields map[string][]int) {
+	var embedded []reflect.StructField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag == "" && isStructDestination(ft) {
+			// Unexported embedded pointers cannot be allocated through reflection.
+			if f.IsExported() || f.Type.Kind() != reflect.Pointer {
+				embedded = append(embedded, f)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = f.Name
+		}
+		name = strings.ToLower(name)
+		if _, exists := fields[name]; !exists {
+			fields[name] = appendIndex(prefix, i)
+		}
+	}
+
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		collectFields(ft, appendIndex(prefix, f.Index[0]), fields)
+	}
+}
+
+// appendIndex returns a new index path extending prefix with idx.
+func appendIndex(prefix []int, idx int) []int {
+	path := make([]int, len(prefix), len(prefix)+1)
+	copy(path, prefix)
+	return append(path, idx)
+}
@@ -0,0 +1,156 @@
+package scan_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg/scan"
+)
+
+// fakeRows serves fixed values, assigning them to destinations like database/sql does.
+type fakeRows struct {
+	columns []string
+	values  [][]any
+	pos     int
+	closed  bool
+	// err is returned by Err once Next has reported the end of rows, as pgx does
+	// for a query failing with the simple protocol.
+	err  error
+	done bool
+}
+
+func (r *fakeRows) Columns() ([]string, error) { return r.columns, nil }
+
+func (r *fakeRows) Next() bool {
+	if r.pos >= len(r.values) {
+		r.done = true
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	row := r.values[r.pos-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %d destinations, got %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if s, ok := d.(sql.Scanner); ok {
+			if err := s.Scan(row[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		dv := reflect.ValueOf(d).Elem()
+		if row[i] == nil {
+			dv.SetZero()
+			continue
+		}
+		v := reflect.ValueOf(row[i])
+		if dv.Kind() == reflect.Pointer {
+			p := reflect.New(dv.Type().Elem())
+			p.Elem().Set(v)
+			v = p
+		}
+		dv.Set(v)
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	if !r.done {
+		return nil
+	}
+	return r.err
+}
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+type Audit struct {
+	CreatedBy string  `db:"created_by"`
+	Note      *string `db:"note"`
+}
+
+type user struct {
+	Audit
+	ID       int64          `db:"id"`
+	Name     string         `db:"name"`
+	Email    sql.NullString `db:"email"`
+	Nickname *string
+	Ignored  string `db:"-"`
+}
+
+func TestAll_MapsTaggedEmbeddedAndNullableFields(t *testing.T) {
+	rows := &fakeRows{
+		columns: []string{"id", "name", "email", "nickname", "created_by", "note"},
+		values: [][]any{
+			{int64(1), "alice", "a@example.com", "al", "admin", "first"},
+			{int64(2), "bob", nil, nil, "system", nil},
+		},
+	}
+
+	users, err := scan.All[user](rows)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.True(t, rows.closed)
+
+	assert.Equal(t, int64(1), users[0].ID)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.Equal(t, sql.NullString{String: "a@example.com", Valid: true}, users[0].Email)
+	require.NotNil(t, users[0].Nickname)
+	assert.Equal(t, "al", *users[0].Nickname)
+	assert.Equal(t, "admin", users[0].CreatedBy)
+	require.NotNil(t, users[0].Note)
+
+	assert.False(t, users[1].Email.Valid)
+	assert.Nil(t, users[1].Nickname)
+	assert.Nil(t, users[1].Note)
+}
+
+func TestAll_UnmappedColumn(t *testing.T) {
+	rows := &fakeRows{columns: []string{"id", "unknown"}}
+
+	_, err := scan.All[user](rows)
+	require.ErrorIs(t, err, scan.ErrUnmappedColumn)
+}
+
+func TestAll_ScalarDestination(t *testing.T) {
+	rows := &fakeRows{columns: []string{"id"}, values: [][]any{{int64(1)}, {int64(2)}}}
+
+	ids, err := scan.All[int64](rows)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	_, err = scan.All[int64](&fakeRows{columns: []string{"id", "name"}})
+	require.ErrorIs(t, err, scan.ErrColumnCount)
+}
+
+func TestOne_ScalarQueryError(t *testing.T) {
+	errQuery := errors.New("relation does not exist")
+
+	_, err := scan.One[int](&fakeRows{err: errQuery})
+	require.ErrorIs(t, err, errQuery)
+	assert.False(t, errors.Is(err, scan.ErrColumnCount))
+
+	_, err = scan.All[int](&fakeRows{err: errQuery})
+	require.ErrorIs(t, err, errQuery)
+}
+
+func TestOne_NotFound(t *testing.T) {
+	_, err := scan.One[user](&fakeRows{columns: []string{"id"}})
+	require.ErrorIs(t, err, scan.ErrNotFound)
+	assert.False(t, errors.Is(err, sql.ErrNoRows))
+
+	u, err := scan.Optional[user](&fakeRows{columns: []string{"id"}})
+	require.NoError(t, err)
+	assert.Nil(t, u)
+}
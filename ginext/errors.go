@@ -0,0 +1,23 @@
+package ginext
+
+import (
+	"net/http"
+
+	"github.com/wb-go/wbf/dbpg/pgerr"
+)
+
+// AbortWithDBError aborts the request with the HTTP status of a database error returned by dbpg
+// or pgxdriver (see pgerr.HTTPStatus) and a JSON body {"error": "..."}. Client errors are described
+// by their kind, e.g. "unique violation"; details of server errors are not exposed. The error is
+// attached to the context for logging middleware.
+func AbortWithDBError(c *Context, err error) {
+	status := pgerr.HTTPStatus(err)
+
+	message := http.StatusText(status)
+	if e, ok := pgerr.From(err); ok && status < http.StatusInternalServerError {
+		message = e.Kind.String()
+	}
+
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(status, H{"error": message})
+}
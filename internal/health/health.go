@@ -0,0 +1,99 @@
+// Package health tracks the availability of a fixed set of nodes, such as read replicas,
+// with periodic concurrent health checks.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checker holds the health flags of n nodes. Nodes are healthy until a check fails.
+type Checker struct {
+	healthy []atomic.Bool
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewChecker creates a checker of n nodes, all of them healthy.
+func NewChecker(n int) *Checker {
+	c := &Checker{healthy: make([]atomic.Bool, n)}
+	for i := range c.healthy {
+		c.healthy[i].Store(true)
+	}
+	return c
+}
+
+// Healthy reports whether the node at idx is healthy.
+func (c *Checker) Healthy(idx int) bool {
+	return c.healthy[idx].Load()
+}
+
+// HealthyCount returns the number of healthy nodes.
+func (c *Checker) HealthyCount() int {
+	count := 0
+	for i := range c.healthy {
+		if c.healthy[i].Load() {
+			count++
+		}
+	}
+	return count
+}
+
+// Start checks every node once, so that nodes that are down at startup never receive
+// traffic, and then again each interval in the background until Stop. A node whose check
+// fails is marked unhealthy and marked healthy again once its check succeeds.
+// Each check is limited by timeout.
+func (c *Checker) Start(interval, timeout time.Duration, check func(ctx context.Context, idx int) bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stop = cancel
+
+	c.checkAll(ctx, timeout, check)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkAll(ctx, timeout, check)
+			}
+		}
+	}()
+}
+
+// Stop stops health checks and waits for the running check to finish.
+func (c *Checker) Stop() {
+	if c.stop != nil {
+		c.stop()
+	}
+	c.wg.Wait()
+}
+
+// checkAll checks all nodes concurrently and updates their health flags.
+func (c *Checker) checkAll(ctx context.Context, timeout time.Duration, check func(ctx context.Context, idx int) bool) {
+	var wg sync.WaitGroup
+	for i := range c.healthy {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			healthy := check(checkCtx, i)
+			if ctx.Err() == nil {
+				c.healthy[i].Store(healthy)
+			}
+		}()
+	}
+	wg.Wait()
+}
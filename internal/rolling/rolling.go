@@ -0,0 +1,67 @@
+// Package rolling provides a time-based rolling window of counters shared by
+// the circuit breaker and the retry budget.
+package rolling
+
+import "time"
+
+// Window is a rolling window split into n fixed-size buckets of type B.
+// Buckets older than the window size are reset to the zero value as time advances.
+// Window is not safe for concurrent use; callers guard it with their own lock.
+type Window[B any] struct {
+	buckets    []B
+	bucketSize time.Duration
+	head       int       // Index of the current bucket.
+	headStart  time.Time // Start time of the current bucket.
+}
+
+// New creates a rolling window of the given total size divided into n buckets.
+// size must be at least n nanoseconds.
+func New[B any](size time.Duration, n int, now time.Time) *Window[B] {
+	return &Window[B]{
+		buckets:    make([]B, n),
+		bucketSize: size / time.Duration(n),
+		headStart:  now,
+	}
+}
+
+// Current returns the bucket covering now.
+func (w *Window[B]) Current(now time.Time) *B {
+	w.advance(now)
+	return &w.buckets[w.head]
+}
+
+// Buckets returns all buckets within the window ending at now.
+// The returned slice is only valid until the next call on the window.
+func (w *Window[B]) Buckets(now time.Time) []B {
+	w.advance(now)
+	return w.buckets
+}
+
+// Reset clears all buckets and starts the window at now.
+func (w *Window[B]) Reset(now time.Time) {
+	clear(w.buckets)
+	w.head = 0
+	w.headStart = now
+}
+
+// advance rotates the window so that the head bucket covers now,
+// clearing every bucket that fell out of the window.
+func (w *Window[B]) advance(now time.Time) {
+	elapsed := now.Sub(w.headStart)
+	if elapsed < w.bucketSize {
+		return
+	}
+
+	steps := int(elapsed / w.bucketSize)
+	if steps >= len(w.buckets) {
+		w.Reset(now)
+		return
+	}
+
+	var zero B
+	for range steps {
+		w.head = (w.head + 1) % len(w.buckets)
+		w.buckets[w.head] = zero
+	}
+	w.headStart = w.headStart.Add(time.Duration(steps) * w.bucketSize)
+}
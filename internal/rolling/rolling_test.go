@@ -0,0 +1,39 @@
+package rolling_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/internal/rolling"
+)
+
+func sum(buckets []int) (total int) {
+	for _, b := range buckets {
+		total += b
+	}
+	return total
+}
+
+func TestWindow_Rotation(t *testing.T) {
+	start := time.Unix(0, 0)
+	w := rolling.New[int](10*time.Second, 10, start)
+
+	*w.Current(start) += 3
+	*w.Current(start.Add(1500 * time.Millisecond)) += 2
+	assert.Equal(t, 5, sum(w.Buckets(start.Add(9*time.Second))))
+
+	// The first bucket falls out of the window, the second one is still inside.
+	assert.Equal(t, 2, sum(w.Buckets(start.Add(10*time.Second))))
+	assert.Equal(t, 0, sum(w.Buckets(start.Add(11*time.Second))))
+
+	*w.Current(start.Add(12 * time.Second))++
+	assert.Equal(t, 1, sum(w.Buckets(start.Add(12*time.Second))))
+
+	// A gap longer than the window clears everything.
+	assert.Equal(t, 0, sum(w.Buckets(start.Add(time.Hour))))
+
+	*w.Current(start.Add(time.Hour)) += 4
+	w.Reset(start.Add(time.Hour))
+	assert.Equal(t, 0, sum(w.Buckets(start.Add(time.Hour))))
+}
@@ -0,0 +1,14 @@
+package kafkav2
+
+import "github.com/wb-go/wbf/circuitbreaker"
+
+// ProducerOption represents a functional configuration option for the Kafka producer.
+type ProducerOption func(*Producer)
+
+// CircuitBreaker attaches a circuit breaker to the producer.
+// While the breaker is open, Send fails fast without contacting the brokers.
+func CircuitBreaker(b *circuitbreaker.Breaker) ProducerOption {
+	return func(p *Producer) {
+		p.breaker = b
+	}
+}
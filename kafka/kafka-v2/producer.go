@@ -5,22 +5,24 @@ import (
 	"fmt"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/wb-go/wbf/circuitbreaker"
 	"github.com/wb-go/wbf/logger"
 )
 
 // Producer wraps kafka.Writer to provide structured logging and consistent error handling.
 // It is configured with strong durability guarantees (RequireAll acks) and a 10-second write timeout.
 type Producer struct {
-	writer *kafka.Writer
-	log    logger.Logger
+	writer  *kafka.Writer
+	log     logger.Logger
+	breaker *circuitbreaker.Breaker
 }
 
 // NewProducer creates a new Kafka producer configured for the given brokers and topic.
 // It uses LeastBytes balancer, requires acknowledgments from all in-sync replicas,
 // and has a 10-second write timeout. All internal logs are routed through the provided logger
-// with structured attributes.
-func NewProducer(brokers []string, topic string, log logger.Logger) *Producer {
-	return &Producer{
+// with structured attributes. Optional behavior is configured via functional options.
+func NewProducer(brokers []string, topic string, log logger.Logger, opts ...ProducerOption) *Producer {
+	p := &Producer{
 		writer: &kafka.Writer{
 			Addr:         kafka.TCP(brokers...),
 			Topic:        topic,
@@ -39,16 +41,24 @@ func NewProducer(brokers []string, topic string, log logger.Logger) *Producer {
 		},
 		log: log,
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
 // Send publishes a single message to the Kafka topic.
 // It wraps any underlying error with a descriptive prefix for easier debugging.
 // The operation respects the provided context for cancellation and timeouts.
 func (p *Producer) Send(ctx context.Context, key, value []byte, headers ...kafka.Header) error {
-	err := p.writer.WriteMessages(ctx, kafka.Message{
-		Key:     key,
-		Value:   value,
-		Headers: headers,
+	err := p.breaker.Execute(func() error {
+		return p.writer.WriteMessages(ctx, kafka.Message{
+			Key:     key,
+			Value:   value,
+			Headers: headers,
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("kafkav2.Producer.Send: %w", err)
@@ -77,15 +77,9 @@ func (c *Consumer) Close() error {
 
 // FetchWithRetry получает сообщение с стратегией повторных попыток.
 func (c *Consumer) FetchWithRetry(ctx context.Context, strategy retry.Strategy) (kafka.Message, error) {
-	var msg kafka.Message
-	err := retry.Do(func() error {
-		m, e := c.Fetch(ctx)
-		if e == nil {
-			msg = m
-		}
-		return e
-	}, strategy)
-	return msg, err
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (kafka.Message, error) {
+		return c.Fetch(ctx)
+	})
 }
 
 // StartConsuming запускает процесс потребления сообщений.
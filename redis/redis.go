@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/wb-go/wbf/circuitbreaker"
 	"github.com/wb-go/wbf/retry"
 )
 
@@ -24,20 +25,23 @@ var (
 // Client wraps the Redis client.
 type Client struct {
 	*redis.Client
+
+	breaker *circuitbreaker.Breaker
 }
 
 // Options contains configuration for Redis connection.
 type Options struct {
-	Address   string // Redis server address (host:port)
-	Password  string // Redis password (optional)
-	MaxMemory string // Max memory limit (e.g., "100mb", "1gb")
-	Policy    string // Memory eviction policy
+	Address        string                  // Redis server address (host:port)
+	Password       string                  // Redis password (optional)
+	MaxMemory      string                  // Max memory limit (e.g., "100mb", "1gb")
+	Policy         string                  // Memory eviction policy
+	CircuitBreaker *circuitbreaker.Breaker // Circuit breaker guarding commands (optional)
 }
 
 // New creates a new Redis client.
 func New(addr, password string, db int) *Client {
 	return &Client{
-		redis.NewClient(&redis.Options{
+		Client: redis.NewClient(&redis.Options{
 			Addr:     addr,
 			Password: password,
 			DB:       db,
@@ -51,10 +55,11 @@ func Connect(options Options) (*Client, error) {
 		return nil, err
 	}
 	client := &Client{
-		redis.NewClient(&redis.Options{
+		Client: redis.NewClient(&redis.Options{
 			Addr:     options.Address,
 			Password: options.Password,
 		}),
+		breaker: options.CircuitBreaker,
 	}
 	ctx := context.Background()
 	client.ConfigSet(ctx, "maxmemory", options.MaxMemory)
@@ -91,24 +96,32 @@ func (c *Client) Ping(ctx context.Context) error {
 
 // Get retrieves a value by key from Redis.
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
-	return c.Client.Get(ctx, key).Result()
+	var val string
+	err := c.execute(func() error {
+		v, e := c.Client.Get(ctx, key).Result()
+		val = v
+		return e
+	})
+	return val, err
 }
 
 // Set stores a value by key in Redis.
 func (c *Client) Set(ctx context.Context, key string, value any) error {
-	return c.Client.Set(ctx, key, value, 0).Err()
+	return c.SetWithExpiration(ctx, key, value, 0)
 }
 
 // SetWithExpiration stores a value with a specified expiration time.
 func (c *Client) SetWithExpiration(ctx context.Context, key string, value any, expiration time.Duration) error {
-	return c.Client.Set(ctx, key, value, expiration).Err()
+	return c.execute(func() error {
+		return c.Client.Set(ctx, key, value, expiration).Err()
+	})
 }
 
 // SetWithExpirationAndRetry stores a value with expiration using a retry strategy.
 func (c *Client) SetWithExpirationAndRetry(ctx context.Context, strategy retry.Strategy,
 	key string, value any, expiration time.Duration) error {
 	return retry.DoContext(ctx, strategy, func() error {
-		return c.Client.Set(ctx, key, value, expiration).Err()
+		return c.SetWithExpiration(ctx, key, value, expiration)
 	})
 }
 
@@ -117,20 +130,21 @@ func (c *Client) SetWithExpirationAndRetry(ctx context.Context, strategy retry.S
 // If expiration is negative, the key will be deleted immediately.
 // Returns an error if the operation fails.
 func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	return c.Client.Expire(ctx, key, expiration).Err()
+	return c.execute(func() error {
+		return c.Client.Expire(ctx, key, expiration).Err()
+	})
 }
 
 // GetWithRetry retrieves a value using a retry strategy.
+// A missing key (NoMatches) is returned immediately without further attempts.
 func (c *Client) GetWithRetry(ctx context.Context, strategy retry.Strategy, key string) (string, error) {
-	var val string
-	err := retry.Do(func() error {
-		v, e := c.Get(ctx, key)
-		if e == nil {
-			val = v
+	return retry.DoValue(ctx, strategy, func(ctx context.Context) (string, error) {
+		val, err := c.Get(ctx, key)
+		if errors.Is(err, NoMatches) {
+			return "", retry.Permanent(err)
 		}
-		return e
-	}, strategy)
-	return val, err
+		return val, err
+	})
 }
 
 // SetWithRetry stores a value using a retry strategy.
@@ -156,7 +170,9 @@ func (c *Client) BatchWriter(ctx context.Context, in <-chan [2]string) {
 
 // Del removes a key from Redis.
 func (c *Client) Del(ctx context.Context, key string) error {
-	return c.Client.Del(ctx, key).Err()
+	return c.execute(func() error {
+		return c.Client.Del(ctx, key).Err()
+	})
 }
 
 // DelWithRetry removes a key from Redis using a retry strategy.
@@ -166,6 +182,24 @@ func (c *Client) DelWithRetry(ctx context.Context, strategy retry.Strategy, key
 	}, strategy)
 }
 
+// execute runs a command through the circuit breaker, if one is configured.
+// A missing key (NoMatches) is returned to the caller but is not counted as a failure.
+func (c *Client) execute(fn func() error) error {
+	var missErr error
+	err := c.breaker.Execute(func() error {
+		e := fn()
+		if errors.Is(e, NoMatches) {
+			missErr = e
+			return nil
+		}
+		return e
+	})
+	if err != nil {
+		return err
+	}
+	return missErr
+}
+
 // Close closes the client, releasing any open resources.
 func (c *Client) Close() error {
 	return c.Client.Close()
@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter определяет способ рандомизации задержки между попытками.
+type Jitter int
+
+const (
+	// NoJitter — задержка растёт строго экспоненциально без рандомизации.
+	NoJitter Jitter = iota
+	// FullJitter — случайная задержка в диапазоне [0, delay].
+	FullJitter
+	// EqualJitter — половина задержки фиксирована, вторая половина случайна: delay/2 + [0, delay/2].
+	EqualJitter
+	// DecorrelatedJitter — случайная задержка в диапазоне [Delay, previous*3],
+	// не зависящая от номера попытки; Backoff в этом режиме не используется.
+	DecorrelatedJitter
+)
+
+// _decorrelatedMultiplier — множитель верхней границы для DecorrelatedJitter.
+const _decorrelatedMultiplier = 3
+
+// backoff вычисляет последовательность задержек для стратегии.
+type backoff struct {
+	strategy Strategy
+	current  time.Duration // Базовая (до рандомизации) задержка следующей попытки.
+	previous time.Duration // Предыдущая выданная задержка (для DecorrelatedJitter).
+}
+
+// newBackoff создаёт генератор задержек для стратегии.
+func newBackoff(strategy Strategy) *backoff {
+	return &backoff{
+		strategy: strategy,
+		current:  strategy.Delay,
+		previous: strategy.Delay,
+	}
+}
+
+// next возвращает задержку перед следующей попыткой с учётом Jitter и MaxDelay.
+func (b *backoff) next() time.Duration {
+	if b.strategy.Jitter == DecorrelatedJitter {
+		b.previous = b.capped(randomBetween(b.strategy.Delay, b.previous*_decorrelatedMultiplier))
+		return b.previous
+	}
+
+	delay := b.capped(b.current)
+	b.current = b.capped(scale(b.current, b.strategy.Backoff))
+
+	switch b.strategy.Jitter {
+	case FullJitter:
+		return randomBetween(0, delay)
+	case EqualJitter:
+		half := delay / 2
+		return half + randomBetween(0, delay-half)
+	case NoJitter, DecorrelatedJitter:
+	}
+	return delay
+}
+
+// capped ограничивает задержку значением MaxDelay, если оно задано.
+func (b *backoff) capped(delay time.Duration) time.Duration {
+	if b.strategy.MaxDelay > 0 && delay > b.strategy.MaxDelay {
+		return b.strategy.MaxDelay
+	}
+	return delay
+}
+
+// scale умножает задержку на коэффициент, защищаясь от переполнения.
+func scale(delay time.Duration, factor float64) time.Duration {
+	next := float64(delay) * factor
+	if next >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(next)
+}
+
+// randomBetween возвращает случайную длительность в диапазоне [lo, hi].
+func randomBetween(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	//nolint:gosec
+	return lo + time.Duration(rand.Int64N(int64(hi-lo)+1))
+}
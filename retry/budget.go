@@ -0,0 +1,168 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/internal/rolling"
+)
+
+const (
+	_defaultBudgetWindow = 10 * time.Second
+	_budgetBuckets       = 10
+)
+
+var (
+	// ErrBudgetExhausted возвращается (вместе с ошибкой последней попытки),
+	// когда общий бюджет повторов исчерпан и очередной повтор запрещён.
+	ErrBudgetExhausted = errors.New("retry budget exhausted")
+	// ErrInvalidBudgetRatio возвращается, если Ratio < 0.
+	ErrInvalidBudgetRatio = errors.New("invalid budget ratio: must be >= 0")
+	// ErrInvalidBudgetMinRetries возвращается, если MinRetries < 0.
+	ErrInvalidBudgetMinRetries = errors.New("invalid budget min retries: must be >= 0")
+	// ErrInvalidBudgetWindow возвращается, если Window < 0 или окно слишком мало для разбиения на интервалы.
+	ErrInvalidBudgetWindow = errors.New("invalid budget window: must be 0 or at least 10ns")
+	// ErrInvalidBudgetRate возвращается, если RatePerSecond < 0 или Burst < 0,
+	// а также если задана скорость пополнения без ёмкости.
+	ErrInvalidBudgetRate = errors.New("invalid budget rate: rate and burst must be >= 0, burst > 0 when rate is set")
+)
+
+// BudgetConfig определяет параметры общего бюджета повторов.
+type BudgetConfig struct {
+	Ratio         float64       // Допустимая доля повторов от числа операций в окне (например, 0.1 — 10%).
+	MinRetries    int           // Число повторов в окне, разрешённое независимо от Ratio.
+	Window        time.Duration // Длительность скользящего окна (0 — 10 секунд).
+	RatePerSecond float64       // Скорость пополнения token bucket для повторов (0 — без ограничения).
+	Burst         int           // Ёмкость token bucket.
+}
+
+// BudgetStats содержит накопительные счётчики бюджета для метрик
+// и значения в текущем скользящем окне.
+type BudgetStats struct {
+	Requests uint64 // Всего операций, учтённых бюджетом.
+	Retries  uint64 // Всего разрешённых повторов.
+	Denied   uint64 // Всего отклонённых повторов.
+
+	WindowRequests int // Операций в текущем окне.
+	WindowRetries  int // Повторов в текущем окне.
+}
+
+// Budget ограничивает долю повторных попыток среди всех операций процесса,
+// предотвращая лавину повторов при сбое зависимости. Один Budget может использоваться
+// несколькими стратегиями и горутинами одновременно.
+type Budget struct {
+	config BudgetConfig
+
+	mu         sync.Mutex
+	window     *rolling.Window[budgetBucket]
+	tokens     float64
+	refilledAt time.Time
+	stats      BudgetStats
+}
+
+// budgetBucket хранит счётчики одного интервала скользящего окна.
+type budgetBucket struct {
+	requests int
+	retries  int
+}
+
+// NewBudget создаёт бюджет повторов с заданной конфигурацией.
+// Возвращает ошибку, если параметры конфигурации некорректны.
+func NewBudget(config BudgetConfig) (*Budget, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if config.Window == 0 {
+		config.Window = _defaultBudgetWindow
+	}
+
+	now := time.Now()
+	return &Budget{
+		config:     config,
+		window:     rolling.New[budgetBucket](config.Window, _budgetBuckets, now),
+		tokens:     float64(config.Burst),
+		refilledAt: now,
+	}, nil
+}
+
+// Stats возвращает снимок счётчиков бюджета.
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	for _, bucket := range b.window.Buckets(time.Now()) {
+		stats.WindowRequests += bucket.requests
+		stats.WindowRetries += bucket.retries
+	}
+	return stats
+}
+
+// recordRequest учитывает новую операцию.
+func (b *Budget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.window.Current(time.Now()).requests++
+	b.stats.Requests++
+}
+
+// allowRetry решает, разрешён ли очередной повтор, и при разрешении учитывает его.
+func (b *Budget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	var requests, retries int
+	for _, bucket := range b.window.Buckets(now) {
+		requests += bucket.requests
+		retries += bucket.retries
+	}
+
+	allowed := float64(retries) < max(float64(b.config.MinRetries), b.config.Ratio*float64(requests))
+	if allowed && b.config.RatePerSecond > 0 {
+		b.refill(now)
+		allowed = b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+	}
+
+	if !allowed {
+		b.stats.Denied++
+		return false
+	}
+
+	b.window.Current(now).retries++
+	b.stats.Retries++
+	return true
+}
+
+// refill пополняет token bucket пропорционально прошедшему времени.
+func (b *Budget) refill(now time.Time) {
+	elapsed := now.Sub(b.refilledAt).Seconds()
+	b.tokens = min(float64(b.config.Burst), b.tokens+elapsed*b.config.RatePerSecond)
+	b.refilledAt = now
+}
+
+// validate проверяет корректность параметров бюджета.
+func (c BudgetConfig) validate() error {
+	if c.Ratio < 0 {
+		return ErrInvalidBudgetRatio
+	}
+
+	if c.MinRetries < 0 {
+		return ErrInvalidBudgetMinRetries
+	}
+
+	if c.Window < 0 || (c.Window > 0 && c.Window < _budgetBuckets) {
+		return ErrInvalidBudgetWindow
+	}
+
+	if c.RatePerSecond < 0 || c.Burst < 0 || (c.RatePerSecond > 0 && c.Burst == 0) {
+		return ErrInvalidBudgetRate
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package retry
+
+import "errors"
+
+// permanentError помечает ошибку как постоянную: повторять попытку бессмысленно.
+type permanentError struct {
+	err error
+}
+
+// Error возвращает текст исходной ошибки.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap возвращает исходную ошибку.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent оборачивает ошибку так, что Do и DoContext прекращают попытки немедленно
+// и возвращают исходную ошибку. Для nil возвращает nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// unwrapPermanent сообщает, помечена ли ошибка как постоянная.
+// Если ошибка сама является обёрткой Permanent, возвращается исходная ошибка;
+// если обёртка вложена глубже, ошибка возвращается как есть, чтобы сохранить контекст.
+func unwrapPermanent(err error) (error, bool) {
+	//nolint:errorlint
+	if perm, ok := err.(*permanentError); ok {
+		return perm.err, true
+	}
+
+	var perm *permanentError
+	return err, errors.As(err, &perm)
+}
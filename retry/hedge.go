@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Hedge выполняет функцию с «подстраховочными» запросами для защиты от хвостовых задержек.
+// Первая попытка запускается сразу; если за delay результат не получен, запускается
+// следующая попытка, и так до maxHedges дополнительных попыток. Неудачная попытка
+// запускает следующую без ожидания. Возвращается первый успешный результат,
+// контексты остальных попыток отменяются, а их результаты отбрасываются.
+// Номер попытки доступен в fn через Attempt(ctx). Ошибка, помеченная Permanent,
+// прекращает все попытки. Если все попытки неудачны, ошибки объединяются через errors.Join.
+// Контекст победившей попытки отменяется перед возвратом, поэтому результат не должен
+// зависеть от него; для таких результатов используйте HedgeWithRelease.
+func Hedge[T any](
+	ctx context.Context,
+	delay time.Duration,
+	maxHedges int,
+	fn func(ctx context.Context) (T, error),
+) (T, error) {
+	val, release, err := HedgeWithRelease(ctx, delay, maxHedges, fn)
+	release()
+	return val, err
+}
+
+// HedgeWithRelease работает как Hedge, но не отменяет контекст победившей попытки:
+// результат, привязанный к контексту, остаётся пригодным после возврата.
+// Возвращаемая функция release отменяет этот контекст и должна быть вызвана,
+// когда результат больше не нужен; до этого контекст попытки остаётся связан с ctx.
+// Если все попытки неудачны, release ничего не делает.
+func HedgeWithRelease[T any](
+	ctx context.Context,
+	delay time.Duration,
+	maxHedges int,
+	fn func(ctx context.Context) (T, error),
+) (T, context.CancelFunc, error) {
+	type result struct {
+		idx int
+		val T
+		err error
+	}
+
+	var zero T
+	total := max(maxHedges, 0) + 1
+	results := make(chan result, total)
+	cancels := make([]context.CancelFunc, 0, total)
+
+	launch := func() {
+		idx := len(cancels)
+		attemptCtx, cancel := context.WithCancel(context.WithValue(ctx, attemptKey{}, idx+1))
+		cancels = append(cancels, cancel)
+		go func() {
+			v, err := fn(attemptCtx)
+			results <- result{idx: idx, val: v, err: err}
+		}()
+	}
+	// Контекст победившей попытки отменяет вызывающий через release.
+	cancelExcept := func(winner int) {
+		for i, cancel := range cancels {
+			if i != winner {
+				cancel()
+			}
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	launch()
+	pending := 1
+
+	var errs []error
+	for {
+		select {
+		case <-ctx.Done():
+			cancelExcept(-1)
+			return zero, func() {}, errors.Join(append(errs, ctx.Err())...)
+		case <-timer.C:
+			if len(cancels) < total {
+				launch()
+				pending++
+				timer.Reset(delay)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				cancelExcept(r.idx)
+				return r.val, cancels[r.idx], nil
+			}
+			cancels[r.idx]()
+
+			if inner, ok := unwrapPermanent(r.err); ok {
+				cancelExcept(-1)
+				return zero, func() {}, errors.Join(append(errs, inner)...)
+			}
+			errs = append(errs, r.err)
+
+			if len(cancels) < total {
+				launch()
+				pending++
+				timer.Reset(delay)
+			} else if pending == 0 {
+				return zero, func() {}, errors.Join(errs...)
+			}
+		}
+	}
+}
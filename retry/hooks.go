@@ -0,0 +1,41 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// retrying уведомляет хук OnRetry и логгер о предстоящей повторной попытке.
+func (s Strategy) retrying(ctx context.Context, attempt int, err error, delay time.Duration) {
+	if s.OnRetry != nil {
+		s.OnRetry(attempt, err, delay)
+	}
+
+	if s.Logger != nil {
+		s.Logger.LogAttrs(ctx, logger.WarnLevel, "retrying operation",
+			logger.Int("attempt", attempt),
+			logger.Int("max_attempts", s.Attempts),
+			logger.String("retry_after", delay.String()),
+			logger.Any("error", err),
+		)
+	}
+}
+
+// giveUp уведомляет хук OnGiveUp и логгер о прекращении попыток
+// и возвращает итоговую ошибку.
+func (s Strategy) giveUp(ctx context.Context, attempts int, err error) error {
+	if s.OnGiveUp != nil {
+		s.OnGiveUp(attempts, err)
+	}
+
+	if s.Logger != nil {
+		s.Logger.LogAttrs(ctx, logger.ErrorLevel, "retry attempts stopped",
+			logger.Int("attempts", attempts),
+			logger.Int("max_attempts", s.Attempts),
+			logger.Any("error", err),
+		)
+	}
+	return err
+}
@@ -3,47 +3,145 @@ package retry
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/wb-go/wbf/logger"
 )
 
+// attemptKey — ключ контекста для номера текущей попытки.
+type attemptKey struct{}
+
 // Strategy определяет параметры поведения повторных попыток.
 type Strategy struct {
-	Attempts int           // Количество попыток.
-	Delay    time.Duration // Начальная задержка между попытками.
-	Backoff  float64       // Множитель для увеличения задержки.
+	Attempts   int                  // Количество попыток.
+	Delay      time.Duration        // Начальная задержка между попытками.
+	Backoff    float64              // Множитель для увеличения задержки.
+	MaxDelay   time.Duration        // Верхняя граница задержки между попытками (0 — без ограничения).
+	MaxElapsed time.Duration        // Общий бюджет времени на все попытки (0 — без ограничения).
+	Jitter     Jitter               // Способ рандомизации задержки (по умолчанию NoJitter).
+	RetryIf    func(err error) bool // Предикат повторной попытки (nil — повторять при любой ошибке).
+	Budget     *Budget              // Общий бюджет повторов (nil — без ограничения).
+
+	OnRetry  func(attempt int, err error, nextDelay time.Duration) // Вызывается перед ожиданием очередной попытки.
+	OnGiveUp func(attempts int, err error)                         // Вызывается, когда попытки прекращены с ошибкой.
+	Logger   logger.Logger                                         // Логгер для записей о повторах (nil — без логов).
 }
 
 // Do выполняет функцию с заданной стратегией повторных попыток.
 func Do(fn func() error, strategy Strategy) error {
-	delay := strategy.Delay
-	var err error
-	for i := 0; i < strategy.Attempts; i++ {
-		err = fn()
-		if err == nil {
-			return nil
-		}
-		time.Sleep(delay)
-		delay = time.Duration(float64(delay) * strategy.Backoff)
-	}
-	return err
+	return do(context.Background(), strategy, func(context.Context) error {
+		return fn()
+	})
 }
 
 // DoContext выполняет функцию с заданной стратегией повторных попыток
 // только с контекстом и завершением при graceful shutdown.
 func DoContext(ctx context.Context, strategy Strategy, fn func() error) error {
-	delay := strategy.Delay
+	return do(ctx, strategy, func(context.Context) error {
+		return fn()
+	})
+}
+
+// DoValue выполняет функцию, возвращающую значение, с заданной стратегией повторных попыток.
+// В каждую попытку передаётся контекст с номером попытки (см. Attempt).
+// При неудаче возвращаются все ошибки попыток, объединённые через errors.Join.
+func DoValue[T any](ctx context.Context, strategy Strategy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var (
+		result T
+		errs   []error
+	)
+	err := do(ctx, strategy, func(ctx context.Context) error {
+		v, e := fn(ctx)
+		if e != nil {
+			inner, _ := unwrapPermanent(e)
+			errs = append(errs, inner)
+			return e
+		}
+		result = v
+		return nil
+	})
+	if err != nil {
+		// Итоговая ошибка либо дополняет ошибку последней попытки (например, ErrBudgetExhausted),
+		// либо является отдельной причиной остановки (например, отменой контекста).
+		if n := len(errs); n > 0 && errors.Is(err, errs[n-1]) {
+			errs[n-1] = err
+		} else {
+			errs = append(errs, err)
+		}
+		var zero T
+		return zero, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// Attempt возвращает номер текущей попытки (начиная с 1) из контекста,
+// переданного в функцию DoValue. Вне повторных попыток возвращает 0.
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// do реализует общий цикл повторных попыток.
+// Цикл прерывается при успехе, постоянной ошибке, отказе предиката RetryIf,
+// исчерпании попыток или бюджета MaxElapsed, а также при отмене контекста.
+// После последней неудачной попытки ожидание не выполняется.
+func do(ctx context.Context, strategy Strategy, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	delays := newBackoff(strategy)
+
+	if strategy.Budget != nil {
+		strategy.Budget.recordRequest()
+	}
+
 	var err error
-	for i := 0; i < strategy.Attempts; i++ {
-		err = fn()
+	for attempt := 1; attempt <= strategy.Attempts; attempt++ {
+		err = fn(context.WithValue(ctx, attemptKey{}, attempt))
 		if err == nil {
 			return nil
 		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
+
+		if inner, ok := unwrapPermanent(err); ok {
+			return strategy.giveUp(ctx, attempt, inner)
+		}
+
+		if attempt == strategy.Attempts || !strategy.shouldRetry(err) {
+			return strategy.giveUp(ctx, attempt, err)
+		}
+
+		delay := delays.next()
+		if strategy.MaxElapsed > 0 && time.Since(start)+delay > strategy.MaxElapsed {
+			return strategy.giveUp(ctx, attempt, err)
+		}
+
+		if strategy.Budget != nil && !strategy.Budget.allowRetry() {
+			return strategy.giveUp(ctx, attempt, fmt.Errorf("%w: %w", ErrBudgetExhausted, err))
+		}
+
+		strategy.retrying(ctx, attempt, err, delay)
+
+		if waitErr := wait(ctx, delay); waitErr != nil {
+			return strategy.giveUp(ctx, attempt, waitErr)
 		}
-		delay = time.Duration(float64(delay) * strategy.Backoff)
 	}
 	return err
 }
+
+// shouldRetry сообщает, допускает ли стратегия повтор после данной ошибки.
+func (s Strategy) shouldRetry(err error) bool {
+	return s.RetryIf == nil || s.RetryIf(err)
+}
+
+// wait ожидает delay или отмены контекста.
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,331 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/retry"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDo_SucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	err := retry.Do(func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	}, retry.Strategy{Attempts: 5, Delay: time.Millisecond, Backoff: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_NoSleepAfterLastAttempt(t *testing.T) {
+	start := time.Now()
+	err := retry.Do(func() error {
+		return errTransient
+	}, retry.Strategy{Attempts: 1, Delay: time.Second, Backoff: 1})
+
+	require.ErrorIs(t, err, errTransient)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestDo_Permanent(t *testing.T) {
+	calls := 0
+	err := retry.Do(func() error {
+		calls++
+		return retry.Permanent(errTransient)
+	}, retry.Strategy{Attempts: 5, Delay: time.Millisecond, Backoff: 1})
+
+	assert.Equal(t, errTransient, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_WrappedPermanentKeepsContext(t *testing.T) {
+	err := retry.Do(func() error {
+		return fmt.Errorf("op: %w", retry.Permanent(errTransient))
+	}, retry.Strategy{Attempts: 5, Delay: time.Millisecond, Backoff: 1})
+
+	require.ErrorIs(t, err, errTransient)
+	assert.Equal(t, "op: transient", err.Error())
+}
+
+func TestDo_RetryIf(t *testing.T) {
+	errFatal := errors.New("fatal")
+	calls := 0
+	err := retry.Do(func() error {
+		calls++
+		if calls == 2 {
+			return errFatal
+		}
+		return errTransient
+	}, retry.Strategy{
+		Attempts: 5,
+		Delay:    time.Millisecond,
+		Backoff:  1,
+		RetryIf:  func(err error) bool { return errors.Is(err, errTransient) },
+	})
+
+	require.ErrorIs(t, err, errFatal)
+	assert.Equal(t, 2, calls)
+}
+
+func TestDo_MaxElapsed(t *testing.T) {
+	calls := 0
+	err := retry.Do(func() error {
+		calls++
+		return errTransient
+	}, retry.Strategy{
+		Attempts:   10,
+		Delay:      20 * time.Millisecond,
+		Backoff:    1,
+		MaxElapsed: 50 * time.Millisecond,
+	})
+
+	require.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: 3, Delay: time.Second, Backoff: 1}, func() error {
+		return errTransient
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDo_JitterRespectsMaxDelay(t *testing.T) {
+	for _, jitter := range []retry.Jitter{retry.FullJitter, retry.EqualJitter, retry.DecorrelatedJitter} {
+		t.Run(fmt.Sprint(jitter), func(t *testing.T) {
+			start := time.Now()
+			_ = retry.Do(func() error {
+				return errTransient
+			}, retry.Strategy{
+				Attempts: 4,
+				Delay:    5 * time.Millisecond,
+				Backoff:  100,
+				MaxDelay: 10 * time.Millisecond,
+				Jitter:   jitter,
+			})
+
+			assert.Less(t, time.Since(start), 200*time.Millisecond)
+		})
+	}
+}
+
+func TestDoValue_ReturnsValueAndAttempt(t *testing.T) {
+	var seen []int
+	val, err := retry.DoValue(context.Background(), retry.Strategy{Attempts: 3, Delay: time.Millisecond, Backoff: 1},
+		func(ctx context.Context) (string, error) {
+			seen = append(seen, retry.Attempt(ctx))
+			if len(seen) < 2 {
+				return "", errTransient
+			}
+			return "ok", nil
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", val)
+	assert.Equal(t, []int{1, 2}, seen)
+}
+
+func TestDoValue_JoinsAttemptErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	calls := 0
+	val, err := retry.DoValue(context.Background(), retry.Strategy{Attempts: 2, Delay: time.Millisecond, Backoff: 1},
+		func(context.Context) (int, error) {
+			calls++
+			if calls == 1 {
+				return 1, errFirst
+			}
+			return 2, errSecond
+		})
+
+	require.ErrorIs(t, err, errFirst)
+	require.ErrorIs(t, err, errSecond)
+	assert.Zero(t, val)
+}
+
+func TestDoValue_ContextCanceledDuringWait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := retry.DoValue(ctx, retry.Strategy{Attempts: 3, Delay: time.Second, Backoff: 1},
+		func(context.Context) (int, error) {
+			return 0, errTransient
+		})
+
+	require.ErrorIs(t, err, errTransient)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAttempt_OutsideRetry(t *testing.T) {
+	assert.Zero(t, retry.Attempt(context.Background()))
+}
+
+func TestDo_Hooks(t *testing.T) {
+	var (
+		retried  []int
+		gaveUp   int
+		finalErr error
+	)
+	err := retry.Do(func() error {
+		return errTransient
+	}, retry.Strategy{
+		Attempts: 3,
+		Delay:    time.Millisecond,
+		Backoff:  2,
+		OnRetry: func(attempt int, err error, nextDelay time.Duration) {
+			retried = append(retried, attempt)
+			assert.ErrorIs(t, err, errTransient)
+			assert.Positive(t, nextDelay)
+		},
+		OnGiveUp: func(attempts int, err error) {
+			gaveUp = attempts
+			finalErr = err
+		},
+	})
+
+	require.ErrorIs(t, err, errTransient)
+	assert.Equal(t, []int{1, 2}, retried)
+	assert.Equal(t, 3, gaveUp)
+	assert.Equal(t, err, finalErr)
+}
+
+func TestBudget_DeniesRetriesOverRatio(t *testing.T) {
+	budget, err := retry.NewBudget(retry.BudgetConfig{Ratio: 0.5, Window: time.Minute})
+	require.NoError(t, err)
+
+	strategy := retry.Strategy{Attempts: 3, Delay: time.Millisecond, Backoff: 1, Budget: budget}
+	for range 3 {
+		require.NoError(t, retry.Do(func() error { return nil }, strategy))
+	}
+
+	calls := 0
+	strategy.Attempts = 5
+	err = retry.Do(func() error {
+		calls++
+		return errTransient
+	}, strategy)
+
+	require.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	require.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls)
+
+	stats := budget.Stats()
+	assert.Equal(t, uint64(4), stats.Requests)
+	assert.Equal(t, uint64(2), stats.Retries)
+	assert.Equal(t, uint64(1), stats.Denied)
+	assert.Equal(t, 4, stats.WindowRequests)
+}
+
+func TestBudget_MinRetriesAndTokenBucket(t *testing.T) {
+	budget, err := retry.NewBudget(retry.BudgetConfig{MinRetries: 10, RatePerSecond: 0.001, Burst: 1})
+	require.NoError(t, err)
+
+	strategy := retry.Strategy{Attempts: 5, Delay: time.Millisecond, Backoff: 1, Budget: budget}
+	calls := 0
+	_, err = retry.DoValue(context.Background(), strategy,
+		func(context.Context) (int, error) {
+			calls++
+			return 0, errTransient
+		})
+
+	require.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	assert.Equal(t, 2, calls)
+}
+
+func TestNewBudget_Validation(t *testing.T) {
+	_, err := retry.NewBudget(retry.BudgetConfig{Ratio: -1})
+	require.ErrorIs(t, err, retry.ErrInvalidBudgetRatio)
+
+	_, err = retry.NewBudget(retry.BudgetConfig{RatePerSecond: 1})
+	require.ErrorIs(t, err, retry.ErrInvalidBudgetRate)
+}
+
+func TestHedge_SecondAttemptWins(t *testing.T) {
+	val, err := retry.Hedge(context.Background(), 10*time.Millisecond, 2, func(ctx context.Context) (int, error) {
+		if retry.Attempt(ctx) == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return retry.Attempt(ctx), nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, val)
+}
+
+func TestHedge_FastPrimaryNoHedges(t *testing.T) {
+	var launched atomic.Int32
+	val, err := retry.Hedge(context.Background(), time.Second, 2, func(context.Context) (string, error) {
+		launched.Add(1)
+		return "primary", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "primary", val)
+	assert.Equal(t, int32(1), launched.Load())
+}
+
+func TestHedge_AllFail(t *testing.T) {
+	_, err := retry.Hedge(context.Background(), time.Second, 2, func(ctx context.Context) (int, error) {
+		return 0, fmt.Errorf("attempt %d: %w", retry.Attempt(ctx), errTransient)
+	})
+
+	require.ErrorIs(t, err, errTransient)
+	assert.Contains(t, err.Error(), "attempt 1")
+	assert.Contains(t, err.Error(), "attempt 3")
+}
+
+// opaqueCtx hides the standard cancel context of its parent, as custom context types do,
+// so that every context.WithCancel derived from it starts a propagation goroutine.
+type opaqueCtx struct{ context.Context }
+
+func (opaqueCtx) Value(any) any { return nil }
+
+func TestHedge_ReleasesWinnerContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := opaqueCtx{parent}
+
+	before := runtime.NumGoroutine()
+	for range 1000 {
+		_, err := retry.Hedge(ctx, time.Second, 1, func(context.Context) (int, error) { return 1, nil })
+		require.NoError(t, err)
+	}
+
+	// Poll in the test goroutine: assert.Eventually runs its condition in a goroutine of its own.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestHedgeWithRelease(t *testing.T) {
+	var winner context.Context
+	_, release, err := retry.HedgeWithRelease(context.Background(), time.Second, 1, func(ctx context.Context) (int, error) {
+		winner = ctx
+		return 1, nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, winner.Err())
+	release()
+	require.ErrorIs(t, winner.Err(), context.Canceled)
+}